@@ -3,6 +3,7 @@
 package ast
 
 import (
+	"fmt"
 	"strconv"
 	"strings"
 
@@ -11,11 +12,15 @@ import (
 	lua "github.com/yuin/gopher-lua"
 )
 
-const headerStr = "// BLACKJACK_VERSION_HEADER"
+const (
+	headerStr   = "// BLACKJACK_VERSION_HEADER"
+	nodeNameStr = "// name: "
+)
 
 // Lexer represents a lexer for the BJK grammar.
 var Lexer = lexer.MustSimple([]lexer.SimpleRule{
 	{"Header", `(?:` + headerStr + `)[ ]*`},
+	{"NodeName", nodeNameStr + `[^\r\n]*`},
 	{"Ident", `[a-zA-Z]\w*`},
 	{"Float", `\-?(?:\d*)?\.\d+`},
 	{"Int", `\-?(?:\d*)?\d+`},
@@ -32,6 +37,10 @@ var Parser = participle.MustBuild[BJK](
 	// it quotes them incorrectly. For example: "reconcil\'d"
 	// participle.Unquote("String"),
 	rustUnquoteOption("String"),
+	participle.Map(func(t lexer.Token) (lexer.Token, error) {
+		t.Value = strings.TrimSpace(strings.TrimPrefix(t.Value, nodeNameStr))
+		return t, nil
+	}, "NodeName"),
 )
 
 func rustUnquoteOption(types ...string) participle.Option {
@@ -104,8 +113,27 @@ type Version struct {
 	Patch int `@Int`
 }
 
+// Compare returns -1, 0, or +1 depending on whether v is older than,
+// the same as, or newer than other.
+func (v Version) Compare(other Version) int {
+	for _, d := range []int{v.Major - other.Major, v.Minor - other.Minor, v.Patch - other.Patch} {
+		switch {
+		case d < 0:
+			return -1
+		case d > 0:
+			return 1
+		}
+	}
+	return 0
+}
+
 // Node represents a node in Blackjack.
 type Node struct {
+	// Name is the full name of the node given by nodes.Builder
+	// (e.g. "Helix.wire-1.CoilPair.coils-1-2"). Blackjack ignores it, so
+	// it is preserved in the BJK file as a "// name: " comment.
+	Name string `@NodeName?`
+
 	OpName      string    `"op_name" ":" @String ","?` // e.g. "MakeScalar"
 	ReturnValue *string   `"return_value" ":" ( "Some" "(" @String ")" | "None" ) ","?`
 	Inputs      []*Input  `"inputs" ":" "[" ( "(" @@* ")" ","? )* "]" ","?`
@@ -160,6 +188,40 @@ func (n *Node) GetOutputs() []string {
 	return names
 }
 
+// FindNode returns the index of the node with the given full name
+// (e.g. "Helix.wire-1"). If no node has that name, a node whose op name
+// matches is returned instead, as long as exactly one does.
+func (g *Graph) FindNode(name string) (uint64, error) {
+	for i, n := range g.Nodes {
+		if n.Name == name {
+			return uint64(i), nil
+		}
+	}
+
+	var matches []uint64
+	for i, n := range g.Nodes {
+		if n.OpName == name {
+			matches = append(matches, uint64(i))
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return 0, fmt.Errorf("node '%v' not found", name)
+	case 1:
+		return matches[0], nil
+	}
+
+	names := make([]string, 0, len(matches))
+	for _, idx := range matches {
+		if n := g.Nodes[idx]; n.Name != "" {
+			names = append(names, n.Name)
+		} else {
+			names = append(names, fmt.Sprintf("node %v", idx))
+		}
+	}
+	return 0, fmt.Errorf("node '%v' is ambiguous; use one of: %v", name, strings.Join(names, ", "))
+}
+
 // Input represents a node's input.
 type Input struct {
 	Name     string         `"name" ":" @String ","*`
@@ -224,10 +286,13 @@ type ParamValue struct {
 }
 
 // ValueEnum represents an enum for a ParamValue.
+// Note that Blackjack writes selections and strings using the same syntax,
+// so the parser always fills StrVal for them. ParseString then converts
+// StrVal to Selection for all inputs whose data_type is "BJK_SELECTION".
 type ValueEnum struct {
 	Scalar    *ScalarValue    `  @@`
-	Selection *SelectionValue `| @@`
 	StrVal    *StringValue    `| @@`
+	Selection *SelectionValue `| @@`
 	Vector    *VectorValue    `| @@`
 }
 
@@ -249,6 +314,8 @@ type StringValue struct {
 }
 
 // SelectionValue is one type of ParamValue.
+// The "Selection" syntax is only accepted for compatibility with
+// older files written by go-bjk. It is always written as "String".
 type SelectionValue struct {
-	Selection string `"String" "(" @String ")" ","?`
+	Selection string `"Selection" "(" @String ")" ","?`
 }
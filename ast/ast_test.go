@@ -760,7 +760,7 @@ var testFileWant = &BJK{
 			{
 				NodeIdx:   1,
 				ParamName: "direction",
-				ValueEnum: ValueEnum{Selection: &SelectionValue{Selection: "Clockwise"}},
+				ValueEnum: ValueEnum{StrVal: &StringValue{S: "Clockwise"}},
 			},
 			{
 				NodeIdx:   14,
@@ -775,17 +775,17 @@ var testFileWant = &BJK{
 			{
 				NodeIdx:   11,
 				ParamName: "op",
-				ValueEnum: ValueEnum{Selection: &SelectionValue{Selection: "Add"}},
+				ValueEnum: ValueEnum{StrVal: &StringValue{S: "Add"}},
 			},
 			{
 				NodeIdx:   4,
 				ParamName: "direction",
-				ValueEnum: ValueEnum{Selection: &SelectionValue{Selection: "Clockwise"}},
+				ValueEnum: ValueEnum{StrVal: &StringValue{S: "Clockwise"}},
 			},
 			{
 				NodeIdx:   13,
 				ParamName: "direction",
-				ValueEnum: ValueEnum{Selection: &SelectionValue{Selection: "Clockwise"}},
+				ValueEnum: ValueEnum{StrVal: &StringValue{S: "Clockwise"}},
 			},
 			{
 				NodeIdx:   2,
@@ -820,7 +820,7 @@ var testFileWant = &BJK{
 			{
 				NodeIdx:   12,
 				ParamName: "op",
-				ValueEnum: ValueEnum{Selection: &SelectionValue{Selection: "Add"}},
+				ValueEnum: ValueEnum{StrVal: &StringValue{S: "Add"}},
 			},
 			{
 				NodeIdx:   13,
@@ -849,7 +849,7 @@ var testFileWant = &BJK{
 			{
 				NodeIdx:   2,
 				ParamName: "direction",
-				ValueEnum: ValueEnum{Selection: &SelectionValue{Selection: "Clockwise"}},
+				ValueEnum: ValueEnum{StrVal: &StringValue{S: "Clockwise"}},
 			},
 			{
 				NodeIdx:   8,
@@ -891,3 +891,44 @@ var testFileWant = &BJK{
 		}},
 	},
 }
+
+func TestGraph_FindNode(t *testing.T) {
+	g := &Graph{
+		Nodes: []*Node{
+			{Name: "HerringboneGear.gear-1", OpName: "HerringboneGear"},
+			{Name: "HerringboneGear.gear-2", OpName: "HerringboneGear"},
+			{Name: "Helix", OpName: "Helix"},
+			{OpName: "MakeScalar"},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		want    uint64
+		wantErr string
+	}{
+		{name: "HerringboneGear.gear-2", want: 1},
+		{name: "Helix", want: 2},
+		{name: "MakeScalar", want: 3},
+		{name: "HerringboneGear", wantErr: "node 'HerringboneGear' is ambiguous; use one of: HerringboneGear.gear-1, HerringboneGear.gear-2"},
+		{name: "HerringboneGear.gear-3", wantErr: "node 'HerringboneGear.gear-3' not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := g.FindNode(tt.name)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("FindNode err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("FindNode = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,298 @@
+package ast
+
+import (
+	"fmt"
+	"hash/fnv"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// DiffNode identifies a node within one of the two designs being compared.
+type DiffNode struct {
+	Index  uint64 `json:"index"`
+	OpName string `json:"op_name"`
+}
+
+func (dn DiffNode) String() string {
+	return fmt.Sprintf("node %v (%v)", dn.Index, dn.OpName)
+}
+
+// NodeMatch pairs a node in design A with its counterpart in design B.
+type NodeMatch struct {
+	A DiffNode `json:"a"`
+	B DiffNode `json:"b"`
+}
+
+// ParamChange represents an external parameter whose value differs between
+// two matched nodes. Before or After is nil if the value is missing on that side.
+type ParamChange struct {
+	NodeMatch
+	ParamName string     `json:"param_name"`
+	Before    *ValueEnum `json:"before,omitempty"`
+	After     *ValueEnum `json:"after,omitempty"`
+}
+
+// ConnectionChange represents an input of two matched nodes whose connection
+// differs. Before refers to node indices in design A and After to those in
+// design B. Either is nil if the input is not connected on that side.
+type ConnectionChange struct {
+	NodeMatch
+	InputName string      `json:"input_name"`
+	Before    *Connection `json:"before,omitempty"`
+	After     *Connection `json:"after,omitempty"`
+}
+
+// DiffResult is the semantic difference between two BJK designs.
+type DiffResult struct {
+	Matched       []*NodeMatch        `json:"matched,omitempty"`
+	Removed       []*DiffNode         `json:"removed,omitempty"`
+	Added         []*DiffNode         `json:"added,omitempty"`
+	ChangedParams []*ParamChange      `json:"changed_params,omitempty"`
+	Rewired       []*ConnectionChange `json:"rewired,omitempty"`
+}
+
+// Empty reports whether the two designs are semantically identical.
+func (d *DiffResult) Empty() bool {
+	return len(d.Removed) == 0 && len(d.Added) == 0 && len(d.ChangedParams) == 0 && len(d.Rewired) == 0
+}
+
+// Diff compares two designs and reports the nodes that were added or removed,
+// the external parameters that changed, and the inputs that were rewired.
+//
+// Because node indices shift whenever nodes are inserted or removed, nodes are
+// matched by their op name and the structure of everything connected upstream
+// of them, first including and then ignoring their parameter values. Nodes that
+// cannot be matched that way are then paired by op name alone. Among equally
+// good candidates, the ones with the closest node indices are paired.
+func Diff(a, b *BJK) *DiffResult {
+	ga, gb := graphOf(a), graphOf(b)
+	result := &DiffResult{}
+
+	aToB := map[uint64]uint64{}
+	bMatched := map[uint64]bool{}
+	// match pairs unmatched nodes having identical keys, preferring the
+	// candidates whose indices are closest to each other.
+	match := func(keysA, keysB []string) {
+		byKey := map[string][]uint64{}
+		for i, key := range keysB {
+			if !bMatched[uint64(i)] {
+				byKey[key] = append(byKey[key], uint64(i))
+			}
+		}
+		var pairs [][2]uint64
+		for i, key := range keysA {
+			if _, ok := aToB[uint64(i)]; ok {
+				continue
+			}
+			for _, bi := range byKey[key] {
+				pairs = append(pairs, [2]uint64{uint64(i), bi})
+			}
+		}
+		sort.SliceStable(pairs, func(i, j int) bool {
+			return absDiff(pairs[i][0], pairs[i][1]) < absDiff(pairs[j][0], pairs[j][1])
+		})
+		for _, p := range pairs {
+			if _, ok := aToB[p[0]]; ok || bMatched[p[1]] {
+				continue
+			}
+			aToB[p[0]] = p[1]
+			bMatched[p[1]] = true
+		}
+	}
+
+	structA, structB := ga.structuralKeys(), gb.structuralKeys()
+	match(ga.keysWithParams(structA), gb.keysWithParams(structB))
+	match(structA, structB)
+	match(ga.opNames(), gb.opNames())
+
+	nodeA := func(i uint64) DiffNode { return DiffNode{Index: i, OpName: ga.Nodes[i].OpName} }
+	nodeB := func(i uint64) DiffNode { return DiffNode{Index: i, OpName: gb.Nodes[i].OpName} }
+
+	paramsA, paramsB := ga.paramValuesByKey(), gb.paramValuesByKey()
+	for i := range ga.Nodes {
+		ai := uint64(i)
+		bi, ok := aToB[ai]
+		if !ok {
+			dn := nodeA(ai)
+			result.Removed = append(result.Removed, &dn)
+			continue
+		}
+		nm := NodeMatch{A: nodeA(ai), B: nodeB(bi)}
+		result.Matched = append(result.Matched, &nm)
+
+		na, nb := ga.Nodes[ai], gb.Nodes[bi]
+		for _, name := range inputNamesUnion(na, nb) {
+			before, after := paramsA[genParamKey(ai, name)], paramsB[genParamKey(bi, name)]
+			if !reflect.DeepEqual(before, after) {
+				result.ChangedParams = append(result.ChangedParams, &ParamChange{
+					NodeMatch: nm,
+					ParamName: name,
+					Before:    before,
+					After:     after,
+				})
+			}
+
+			var connA, connB *Connection
+			if input, ok := na.GetInput(name); ok {
+				connA = input.Kind.Connection
+			}
+			if input, ok := nb.GetInput(name); ok {
+				connB = input.Kind.Connection
+			}
+			if connA == nil && connB == nil {
+				continue
+			}
+			if connA != nil && connB != nil && connA.ParamName == connB.ParamName {
+				if mapped, ok := aToB[connA.NodeIdx]; ok && mapped == connB.NodeIdx {
+					continue
+				}
+			}
+			result.Rewired = append(result.Rewired, &ConnectionChange{
+				NodeMatch: nm,
+				InputName: name,
+				Before:    connA,
+				After:     connB,
+			})
+		}
+	}
+
+	for i := range gb.Nodes {
+		if !bMatched[uint64(i)] {
+			dn := nodeB(uint64(i))
+			result.Added = append(result.Added, &dn)
+		}
+	}
+
+	return result
+}
+
+func graphOf(design *BJK) *Graph {
+	if design == nil || design.Graph == nil {
+		return &Graph{}
+	}
+	return design.Graph
+}
+
+func (g *Graph) opNames() []string {
+	names := make([]string, 0, len(g.Nodes))
+	for _, n := range g.Nodes {
+		names = append(names, n.OpName)
+	}
+	return names
+}
+
+// structuralKeys returns a hash for each node that is derived from its op name
+// and (recursively) the nodes and outputs connected to each of its inputs.
+// If the graph has a cycle, the op names are used instead.
+func (g *Graph) structuralKeys() []string {
+	order, err := g.TopologicalOrder()
+	if err != nil {
+		return g.opNames()
+	}
+
+	keys := make([]string, len(g.Nodes))
+	for _, idx := range order {
+		n := g.Nodes[idx]
+		parts := []string{n.OpName}
+		for _, input := range n.Inputs {
+			if conn := input.Kind.Connection; conn != nil && conn.NodeIdx < uint64(len(g.Nodes)) {
+				parts = append(parts, fmt.Sprintf("%v=%v.%v", input.Name, keys[conn.NodeIdx], conn.ParamName))
+			}
+		}
+		h := fnv.New64a()
+		h.Write([]byte(strings.Join(parts, ",")))
+		keys[idx] = fmt.Sprintf("%x", h.Sum64())
+	}
+	return keys
+}
+
+// keysWithParams extends keys with the values of each node's external parameters.
+func (g *Graph) keysWithParams(keys []string) []string {
+	params := g.paramValuesByKey()
+	result := make([]string, 0, len(keys))
+	for i, n := range g.Nodes {
+		parts := []string{keys[i]}
+		for _, name := range n.GetInputs() {
+			if ve, ok := params[genParamKey(uint64(i), name)]; ok {
+				parts = append(parts, fmt.Sprintf("%v=%#v", name, ve.String()))
+			}
+		}
+		result = append(result, strings.Join(parts, ","))
+	}
+	return result
+}
+
+func absDiff(a, b uint64) uint64 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+func genParamKey(nodeIdx uint64, paramName string) string {
+	return fmt.Sprintf("%v,%v", nodeIdx, paramName)
+}
+
+func (g *Graph) paramValuesByKey() map[string]*ValueEnum {
+	result := map[string]*ValueEnum{}
+	if g.ExternalParameters == nil {
+		return result
+	}
+	for _, pv := range g.ExternalParameters.ParamValues {
+		result[genParamKey(pv.NodeIdx, pv.ParamName)] = &pv.ValueEnum
+	}
+	return result
+}
+
+func inputNamesUnion(a, b *Node) []string {
+	names := a.GetInputs()
+	for _, name := range b.GetInputs() {
+		if _, ok := a.GetInput(name); !ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// String returns a human-readable report of the differences.
+func (d *DiffResult) String() string {
+	var lines []string
+	f := func(fmtStr string, args ...any) { lines = append(lines, fmt.Sprintf(fmtStr, args...)) }
+
+	for _, dn := range d.Removed {
+		f("- %v", dn)
+	}
+	for _, dn := range d.Added {
+		f("+ %v", dn)
+	}
+	for _, pc := range d.ChangedParams {
+		f("~ %v: param %q: %v -> %v", pc.NodeMatch, pc.ParamName, valueEnumOrNone(pc.Before), valueEnumOrNone(pc.After))
+	}
+	for _, cc := range d.Rewired {
+		f("~ %v: input %q: %v -> %v", cc.NodeMatch, cc.InputName, connectionOrNone(cc.Before), connectionOrNone(cc.After))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func (nm NodeMatch) String() string {
+	if nm.A.Index == nm.B.Index {
+		return nm.A.String()
+	}
+	return fmt.Sprintf("%v [now node %v]", nm.A, nm.B.Index)
+}
+
+func valueEnumOrNone(ve *ValueEnum) string {
+	if ve == nil {
+		return "None"
+	}
+	return ve.String()
+}
+
+func connectionOrNone(conn *Connection) string {
+	if conn == nil {
+		return "None"
+	}
+	return fmt.Sprintf("node %v.%v", conn.NodeIdx, conn.ParamName)
+}
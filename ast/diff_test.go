@@ -0,0 +1,138 @@
+package ast
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func parseTestFile(t *testing.T) *BJK {
+	t.Helper()
+	design, err := ParseString("", testFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return design
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name          string
+		edit          func(t *testing.T, g *Graph)
+		wantRemoved   []*DiffNode
+		wantAdded     []*DiffNode
+		wantParams    []*ParamChange
+		wantRewired   []*ConnectionChange
+		wantMatchedAt map[uint64]uint64
+	}{
+		{
+			name: "identical",
+		},
+		{
+			name: "inserted node shifts indices",
+			edit: func(t *testing.T, g *Graph) {
+				if err := g.InsertNode(0, &Node{OpName: "MakeComment"}, nil); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantAdded:     []*DiffNode{{Index: 0, OpName: "MakeComment"}},
+			wantMatchedAt: map[uint64]uint64{0: 1, 15: 16},
+		},
+		{
+			name: "changed parameter",
+			edit: func(t *testing.T, g *Graph) {
+				for _, pv := range g.ExternalParameters.ParamValues {
+					if pv.NodeIdx == 7 && pv.ParamName == "x" {
+						pv.ValueEnum.Scalar.X = 3
+					}
+				}
+			},
+			wantParams: []*ParamChange{{
+				NodeMatch: NodeMatch{A: DiffNode{Index: 7, OpName: "MakeScalar"}, B: DiffNode{Index: 7, OpName: "MakeScalar"}},
+				ParamName: "x",
+				Before:    &ValueEnum{Scalar: &ScalarValue{X: 2}},
+				After:     &ValueEnum{Scalar: &ScalarValue{X: 3}},
+			}},
+		},
+		{
+			name: "rewired connection",
+			edit: func(t *testing.T, g *Graph) {
+				// Helix.wire-1 (node 1) size: VectorMath.vert-gap-2 (node 12) => VectorMath.vert-gap-1 (node 11)
+				input, _ := g.Nodes[1].GetInput("size")
+				input.Kind.Connection.NodeIdx = 11
+			},
+			wantRewired: []*ConnectionChange{{
+				NodeMatch: NodeMatch{A: DiffNode{Index: 1, OpName: "Helix"}, B: DiffNode{Index: 1, OpName: "Helix"}},
+				InputName: "size",
+				Before:    &Connection{NodeIdx: 12, ParamName: "out"},
+				After:     &Connection{NodeIdx: 11, ParamName: "out"},
+			}},
+		},
+		{
+			name: "removed node",
+			edit: func(t *testing.T, g *Graph) {
+				if err := g.DeleteNode(15); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantRemoved: []*DiffNode{{Index: 15, OpName: "MergeMeshes"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := parseTestFile(t), parseTestFile(t)
+			if tt.edit != nil {
+				tt.edit(t, b.Graph)
+			}
+
+			got := Diff(a, b)
+			if diff := cmp.Diff(tt.wantRemoved, got.Removed); diff != "" {
+				t.Errorf("Removed mismatch (-want +got):\n%v", diff)
+			}
+			if diff := cmp.Diff(tt.wantAdded, got.Added); diff != "" {
+				t.Errorf("Added mismatch (-want +got):\n%v", diff)
+			}
+			if diff := cmp.Diff(tt.wantParams, got.ChangedParams); diff != "" {
+				t.Errorf("ChangedParams mismatch (-want +got):\n%v", diff)
+			}
+			if diff := cmp.Diff(tt.wantRewired, got.Rewired); diff != "" {
+				t.Errorf("Rewired mismatch (-want +got):\n%v", diff)
+			}
+			if got.Empty() != (tt.edit == nil) {
+				t.Errorf("Empty = %v, want %v:\n%v", got.Empty(), tt.edit == nil, got)
+			}
+			for ai, bi := range tt.wantMatchedAt {
+				var found bool
+				for _, m := range got.Matched {
+					if m.A.Index == ai {
+						found = true
+						if m.B.Index != bi {
+							t.Errorf("node %v matched to %v, want %v", ai, m.B.Index, bi)
+						}
+					}
+				}
+				if !found {
+					t.Errorf("node %v not matched", ai)
+				}
+			}
+		})
+	}
+}
+
+func TestDiff_JSON(t *testing.T) {
+	d := &DiffResult{Rewired: []*ConnectionChange{{
+		NodeMatch: NodeMatch{A: DiffNode{Index: 1, OpName: "Helix"}, B: DiffNode{Index: 2, OpName: "Helix"}},
+		InputName: "size",
+		Before:    &Connection{NodeIdx: 12, ParamName: "out"},
+	}}}
+	got, err := json.Marshal(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"rewired":[{"a":{"index":1,"op_name":"Helix"},"b":{"index":2,"op_name":"Helix"},"input_name":"size","before":{"node_idx":12,"param_name":"out"}}]}`
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Errorf("json.Marshal mismatch (-want +got):\n%v", diff)
+	}
+}
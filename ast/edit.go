@@ -0,0 +1,223 @@
+package ast
+
+import (
+	"fmt"
+)
+
+// InsertNode inserts node n into the graph at index idx (0..len(g.Nodes)),
+// renumbering every index in the graph that refers to a node at or after idx.
+// If pos is nil, n.NodePosition (if set) is used for the UI, else (0,0).
+// Any Connection.NodeIdx values within n itself must already refer to
+// the indices of the graph as they will be after the insertion.
+func (g *Graph) InsertNode(idx uint64, n *Node, pos *Vec2) error {
+	numNodes := uint64(len(g.Nodes))
+	if idx > numNodes {
+		return fmt.Errorf("InsertNode: index %v out of range, want 0..%v", idx, numNodes)
+	}
+	if n == nil {
+		return fmt.Errorf("InsertNode: node cannot be nil")
+	}
+
+	g.remap(func(old uint64) (uint64, bool) {
+		if old >= idx {
+			return old + 1, true
+		}
+		return old, true
+	}, nil)
+
+	g.Nodes = append(g.Nodes[:idx], append([]*Node{n}, g.Nodes[idx:]...)...)
+
+	if ui := g.UIData; ui != nil {
+		if pos == nil {
+			pos = n.NodePosition
+		}
+		if pos == nil {
+			pos = &Vec2{}
+		}
+		if idx <= uint64(len(ui.NodePositions)) {
+			ui.NodePositions = append(ui.NodePositions[:idx], append([]*Vec2{pos}, ui.NodePositions[idx:]...)...)
+		}
+		ui.NodeOrder = append(ui.NodeOrder, idx)
+	}
+
+	g.renumberNodes()
+	return nil
+}
+
+// DeleteNode removes the node at index idx along with its external parameters
+// and UI data, renumbering every index in the graph that refers to a later node.
+// It is an error to delete a node whose outputs are still connected to other nodes.
+// If idx was the default node, the default node is cleared.
+func (g *Graph) DeleteNode(idx uint64) error {
+	numNodes := uint64(len(g.Nodes))
+	if idx >= numNodes {
+		return fmt.Errorf("DeleteNode: index %v out of range, %v", idx, validRange(numNodes))
+	}
+	if down := g.Downstream(idx); len(down) > 0 {
+		return fmt.Errorf("DeleteNode: node %v (%v) is still connected to nodes %+v", idx, g.Nodes[idx].OpName, down)
+	}
+
+	if ui := g.UIData; ui != nil && idx < uint64(len(ui.NodePositions)) {
+		ui.NodePositions = append(ui.NodePositions[:idx], ui.NodePositions[idx+1:]...)
+	}
+
+	g.remap(func(old uint64) (uint64, bool) {
+		switch {
+		case old == idx:
+			return 0, false
+		case old > idx:
+			return old - 1, true
+		}
+		return old, true
+	}, nil)
+
+	g.Nodes = append(g.Nodes[:idx], g.Nodes[idx+1:]...)
+
+	g.renumberNodes()
+	return nil
+}
+
+// MoveNode moves the node at index from to index to, shifting the nodes
+// in between and renumbering every index in the graph accordingly.
+func (g *Graph) MoveNode(from, to uint64) error {
+	numNodes := uint64(len(g.Nodes))
+	if from >= numNodes {
+		return fmt.Errorf("MoveNode: 'from' index %v out of range, %v", from, validRange(numNodes))
+	}
+	if to >= numNodes {
+		return fmt.Errorf("MoveNode: 'to' index %v out of range, %v", to, validRange(numNodes))
+	}
+	if from == to {
+		return nil
+	}
+
+	newIndex := func(old uint64) uint64 {
+		switch {
+		case old == from:
+			return to
+		case from < to && old > from && old <= to:
+			return old - 1
+		case from > to && old >= to && old < from:
+			return old + 1
+		}
+		return old
+	}
+
+	nodes := make([]*Node, numNodes)
+	for i, n := range g.Nodes {
+		nodes[newIndex(uint64(i))] = n
+	}
+
+	if ui := g.UIData; ui != nil && uint64(len(ui.NodePositions)) == numNodes {
+		positions := make([]*Vec2, numNodes)
+		for i, pos := range ui.NodePositions {
+			positions[newIndex(uint64(i))] = pos
+		}
+		ui.NodePositions = positions
+	}
+
+	g.remap(func(old uint64) (uint64, bool) { return newIndex(old), true }, nil)
+	g.Nodes = nodes
+
+	g.renumberNodes()
+	return nil
+}
+
+// ReplaceNode replaces the node at index idx with n, keeping its index and UI data.
+// Connections from other nodes must refer to outputs that n also provides.
+// External parameters for inputs that n does not have as unconnected inputs are removed.
+func (g *Graph) ReplaceNode(idx uint64, n *Node) error {
+	numNodes := uint64(len(g.Nodes))
+	if idx >= numNodes {
+		return fmt.Errorf("ReplaceNode: index %v out of range, %v", idx, validRange(numNodes))
+	}
+	if n == nil {
+		return fmt.Errorf("ReplaceNode: node cannot be nil")
+	}
+
+	for _, down := range g.Downstream(idx) {
+		for _, input := range g.Nodes[down].Inputs {
+			conn := input.Kind.Connection
+			if conn == nil || conn.NodeIdx != idx {
+				continue
+			}
+			if _, ok := n.GetOutput(conn.ParamName); !ok {
+				return fmt.Errorf("ReplaceNode: node %v input %q is connected to output %q which %v does not provide; valid choices are: %+v", down, input.Name, conn.ParamName, n.OpName, n.GetOutputs())
+			}
+		}
+	}
+
+	g.Nodes[idx] = n
+	g.remap(func(old uint64) (uint64, bool) { return old, true }, func(pv *ParamValue) bool {
+		if pv.NodeIdx != idx {
+			return true
+		}
+		input, ok := n.GetInput(pv.ParamName)
+		return ok && input.Kind.Connection == nil
+	})
+
+	g.renumberNodes()
+	return nil
+}
+
+// remap rewrites every node index in the graph (except for the order of
+// g.Nodes and UIData.NodePositions, which are handled by the caller).
+// If fn returns false, the index is removed (or for DefaultNode, cleared).
+// keepPV optionally filters the external parameters after remapping.
+func (g *Graph) remap(fn func(old uint64) (uint64, bool), keepPV func(pv *ParamValue) bool) {
+	for _, n := range g.Nodes {
+		for _, input := range n.Inputs {
+			if conn := input.Kind.Connection; conn != nil {
+				if v, ok := fn(conn.NodeIdx); ok {
+					conn.NodeIdx = v
+				}
+			}
+		}
+	}
+
+	if g.DefaultNode != nil {
+		if v, ok := fn(*g.DefaultNode); ok {
+			g.DefaultNode = &v
+		} else {
+			g.DefaultNode = nil
+		}
+	}
+
+	remapSlice := func(s []uint64) []uint64 {
+		var result []uint64
+		for _, old := range s {
+			if v, ok := fn(old); ok {
+				result = append(result, v)
+			}
+		}
+		return result
+	}
+
+	if ui := g.UIData; ui != nil {
+		ui.NodeOrder = remapSlice(ui.NodeOrder)
+		ui.LockedGizmoNodes = remapSlice(ui.LockedGizmoNodes)
+	}
+
+	if ep := g.ExternalParameters; ep != nil {
+		var pvs []*ParamValue
+		for _, pv := range ep.ParamValues {
+			v, ok := fn(pv.NodeIdx)
+			if !ok {
+				continue
+			}
+			pv.NodeIdx = v
+			if keepPV != nil && !keepPV(pv) {
+				continue
+			}
+			pvs = append(pvs, pv)
+		}
+		ep.ParamValues = pvs
+	}
+}
+
+// renumberNodes keeps each Node.Index in sync with its position in the graph.
+func (g *Graph) renumberNodes() {
+	for i, n := range g.Nodes {
+		n.Index = uint64(i)
+	}
+}
@@ -0,0 +1,206 @@
+package ast
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+// editGraph returns validGraph with a third (unconnected) MakeBox node
+// and locked gizmo nodes so that every index-bearing field is populated.
+func editGraph() *Graph {
+	g := validGraph()
+	g.Nodes = append(g.Nodes, &Node{
+		OpName:  "MakeBox",
+		Inputs:  []*Input{{Name: "size", DataType: "BJK_VECTOR", Kind: DependencyKind{External: &External{}}}},
+		Outputs: []*Output{{Name: "out_mesh", DataType: "BJK_MESH"}},
+	})
+	g.UIData.NodePositions = append(g.UIData.NodePositions, &Vec2{X: 200})
+	g.UIData.NodeOrder = []uint64{2, 0, 1}
+	g.UIData.LockedGizmoNodes = []uint64{1, 2}
+	ep := g.ExternalParameters
+	ep.ParamValues = append(ep.ParamValues, &ParamValue{NodeIdx: 2, ParamName: "size", ValueEnum: ValueEnum{Vector: &VectorValue{X: 1, Y: 1, Z: 1}}})
+	g.renumberNodes()
+	return g
+}
+
+// summary captures every index-bearing field of a graph for comparison.
+type summary struct {
+	OpNames          []string
+	Connections      map[string]Connection
+	DefaultNode      *uint64
+	Positions        []float64
+	NodeOrder        []uint64
+	LockedGizmoNodes []uint64
+	ParamValues      []string
+}
+
+func summarize(t *testing.T, g *Graph) *summary {
+	t.Helper()
+	if err := g.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+
+	s := &summary{
+		Connections:      map[string]Connection{},
+		DefaultNode:      g.DefaultNode,
+		NodeOrder:        g.UIData.NodeOrder,
+		LockedGizmoNodes: g.UIData.LockedGizmoNodes,
+	}
+	for i, n := range g.Nodes {
+		if n.Index != uint64(i) {
+			t.Errorf("node %v has Index %v", i, n.Index)
+		}
+		s.OpNames = append(s.OpNames, n.OpName)
+		for _, input := range n.Inputs {
+			if conn := input.Kind.Connection; conn != nil {
+				s.Connections[n.OpName+"."+input.Name] = *conn
+			}
+		}
+	}
+	for _, pos := range g.UIData.NodePositions {
+		s.Positions = append(s.Positions, pos.X)
+	}
+	for _, pv := range g.ExternalParameters.ParamValues {
+		s.ParamValues = append(s.ParamValues, g.Nodes[pv.NodeIdx].OpName+"."+pv.ParamName)
+	}
+	return s
+}
+
+func TestGraphEdits(t *testing.T) {
+	tests := []struct {
+		name    string
+		edit    func(g *Graph) error
+		want    *summary
+		wantErr bool
+	}{
+		{
+			name: "insert at start",
+			edit: func(g *Graph) error {
+				return g.InsertNode(0, &Node{OpName: "MakeComment"}, &Vec2{X: -100})
+			},
+			want: &summary{
+				OpNames:          []string{"MakeComment", "MakeScalar", "Helix", "MakeBox"},
+				Connections:      map[string]Connection{"Helix.turns": {NodeIdx: 1, ParamName: "x"}},
+				DefaultNode:      Uint64(2),
+				Positions:        []float64{-100, 0, 100, 200},
+				NodeOrder:        []uint64{3, 1, 2, 0},
+				LockedGizmoNodes: []uint64{2, 3},
+				ParamValues:      []string{"MakeScalar.x", "Helix.segments", "MakeBox.size"},
+			},
+		},
+		{
+			name: "insert at end",
+			edit: func(g *Graph) error {
+				return g.InsertNode(3, &Node{OpName: "MakeComment", NodePosition: &Vec2{X: 300}}, nil)
+			},
+			want: &summary{
+				OpNames:          []string{"MakeScalar", "Helix", "MakeBox", "MakeComment"},
+				Connections:      map[string]Connection{"Helix.turns": {NodeIdx: 0, ParamName: "x"}},
+				DefaultNode:      Uint64(1),
+				Positions:        []float64{0, 100, 200, 300},
+				NodeOrder:        []uint64{2, 0, 1, 3},
+				LockedGizmoNodes: []uint64{1, 2},
+				ParamValues:      []string{"MakeScalar.x", "Helix.segments", "MakeBox.size"},
+			},
+		},
+		{
+			name:    "insert out of range",
+			edit:    func(g *Graph) error { return g.InsertNode(4, &Node{}, nil) },
+			wantErr: true,
+		},
+		{
+			name: "delete unconnected node",
+			edit: func(g *Graph) error { return g.DeleteNode(1) },
+			want: &summary{
+				OpNames:          []string{"MakeScalar", "MakeBox"},
+				Connections:      map[string]Connection{},
+				Positions:        []float64{0, 200},
+				NodeOrder:        []uint64{1, 0},
+				LockedGizmoNodes: []uint64{1},
+				ParamValues:      []string{"MakeScalar.x", "MakeBox.size"},
+			},
+		},
+		{
+			name:    "delete connected node",
+			edit:    func(g *Graph) error { return g.DeleteNode(0) },
+			wantErr: true,
+		},
+		{
+			name: "move first to last",
+			edit: func(g *Graph) error { return g.MoveNode(0, 2) },
+			want: &summary{
+				OpNames:          []string{"Helix", "MakeBox", "MakeScalar"},
+				Connections:      map[string]Connection{"Helix.turns": {NodeIdx: 2, ParamName: "x"}},
+				DefaultNode:      Uint64(0),
+				Positions:        []float64{100, 200, 0},
+				NodeOrder:        []uint64{1, 2, 0},
+				LockedGizmoNodes: []uint64{0, 1},
+				ParamValues:      []string{"MakeScalar.x", "Helix.segments", "MakeBox.size"},
+			},
+		},
+		{
+			name: "move last to first",
+			edit: func(g *Graph) error { return g.MoveNode(2, 0) },
+			want: &summary{
+				OpNames:          []string{"MakeBox", "MakeScalar", "Helix"},
+				Connections:      map[string]Connection{"Helix.turns": {NodeIdx: 1, ParamName: "x"}},
+				DefaultNode:      Uint64(2),
+				Positions:        []float64{200, 0, 100},
+				NodeOrder:        []uint64{0, 1, 2},
+				LockedGizmoNodes: []uint64{2, 0},
+				ParamValues:      []string{"MakeScalar.x", "Helix.segments", "MakeBox.size"},
+			},
+		},
+		{
+			name: "replace node",
+			edit: func(g *Graph) error {
+				return g.ReplaceNode(2, &Node{
+					OpName:  "MakeQuad",
+					Inputs:  []*Input{{Name: "normal", DataType: "BJK_VECTOR", Kind: DependencyKind{External: &External{}}}},
+					Outputs: []*Output{{Name: "out_mesh", DataType: "BJK_MESH"}},
+				})
+			},
+			want: &summary{
+				OpNames:          []string{"MakeScalar", "Helix", "MakeQuad"},
+				Connections:      map[string]Connection{"Helix.turns": {NodeIdx: 0, ParamName: "x"}},
+				DefaultNode:      Uint64(1),
+				Positions:        []float64{0, 100, 200},
+				NodeOrder:        []uint64{2, 0, 1},
+				LockedGizmoNodes: []uint64{1, 2},
+				ParamValues:      []string{"MakeScalar.x", "Helix.segments"},
+			},
+		},
+		{
+			name: "replace node missing connected output",
+			edit: func(g *Graph) error {
+				return g.ReplaceNode(0, &Node{OpName: "MakeVector", Outputs: []*Output{{Name: "v"}}})
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := editGraph()
+			err := tt.edit(g)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				if diff := cmp.Diff(summarize(t, editGraph()), summarize(t, g)); diff != "" {
+					t.Errorf("graph modified on error (-want +got):\n%v", diff)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(tt.want, summarize(t, g), cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("edit mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
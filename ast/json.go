@@ -0,0 +1,390 @@
+package ast
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// The AST structs carry participle grammar tags, so their JSON encoding is
+// defined by the json* types below instead. The encoding uses the same
+// snake_case names as the BJK file and omits fields that are not preserved
+// in the BJK file (e.g. Node.Label or Input.Props).
+//
+// Only JSON is supported: a YAML encoding would require a third-party
+// dependency, and since JSON is a subset of YAML 1.2, YAML tooling can
+// read the JSON encoding as-is.
+
+type jsonBJK struct {
+	Version jsonVersion `json:"version"`
+	Graph   *Graph      `json:"graph"`
+}
+
+type jsonVersion struct {
+	Major int `json:"major"`
+	Minor int `json:"minor"`
+	Patch int `json:"patch"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (b *BJK) MarshalJSON() ([]byte, error) {
+	v := b.Version
+	return json.Marshal(&jsonBJK{
+		Version: jsonVersion{Major: v.Major, Minor: v.Minor, Patch: v.Patch},
+		Graph:   b.Graph,
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (b *BJK) UnmarshalJSON(data []byte) error {
+	var v jsonBJK
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	b.Version = Version{Major: v.Version.Major, Minor: v.Version.Minor, Patch: v.Version.Patch}
+	b.Graph = v.Graph
+	return nil
+}
+
+type jsonGraph struct {
+	Nodes              []*Node             `json:"nodes"`
+	DefaultNode        *uint64             `json:"default_node"`
+	UIData             *UIData             `json:"ui_data"`
+	ExternalParameters *ExternalParameters `json:"external_parameters"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (g *Graph) MarshalJSON() ([]byte, error) {
+	nodes := g.Nodes
+	if nodes == nil {
+		nodes = []*Node{}
+	}
+	return json.Marshal(&jsonGraph{
+		Nodes:              nodes,
+		DefaultNode:        g.DefaultNode,
+		UIData:             g.UIData,
+		ExternalParameters: g.ExternalParameters,
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (g *Graph) UnmarshalJSON(data []byte) error {
+	var v jsonGraph
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if len(v.Nodes) == 0 {
+		v.Nodes = nil
+	}
+	*g = Graph{
+		Nodes:              v.Nodes,
+		DefaultNode:        v.DefaultNode,
+		UIData:             v.UIData,
+		ExternalParameters: v.ExternalParameters,
+	}
+	return nil
+}
+
+type jsonNode struct {
+	Name        string    `json:"name,omitempty"`
+	OpName      string    `json:"op_name"`
+	ReturnValue *string   `json:"return_value"`
+	Inputs      []*Input  `json:"inputs"`
+	Outputs     []*Output `json:"outputs"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (n *Node) MarshalJSON() ([]byte, error) {
+	inputs, outputs := n.Inputs, n.Outputs
+	if inputs == nil {
+		inputs = []*Input{}
+	}
+	if outputs == nil {
+		outputs = []*Output{}
+	}
+	return json.Marshal(&jsonNode{
+		Name:        n.Name,
+		OpName:      n.OpName,
+		ReturnValue: n.ReturnValue,
+		Inputs:      inputs,
+		Outputs:     outputs,
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (n *Node) UnmarshalJSON(data []byte) error {
+	var v jsonNode
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if len(v.Inputs) == 0 {
+		v.Inputs = nil
+	}
+	if len(v.Outputs) == 0 {
+		v.Outputs = nil
+	}
+	*n = Node{
+		Name:        v.Name,
+		OpName:      v.OpName,
+		ReturnValue: v.ReturnValue,
+		Inputs:      v.Inputs,
+		Outputs:     v.Outputs,
+	}
+	return nil
+}
+
+type jsonInput struct {
+	Name     string         `json:"name"`
+	DataType string         `json:"data_type"`
+	Kind     jsonDependency `json:"kind"`
+}
+
+// jsonDependency is exactly one of External or Connection.
+type jsonDependency struct {
+	External   *jsonExternal   `json:"external,omitempty"`
+	Connection *jsonConnection `json:"connection,omitempty"`
+}
+
+type jsonExternal struct {
+	Promoted *string `json:"promoted"`
+}
+
+type jsonConnection struct {
+	NodeIdx   uint64 `json:"node_idx"`
+	ParamName string `json:"param_name"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (c *Connection) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&jsonConnection{NodeIdx: c.NodeIdx, ParamName: c.ParamName})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (c *Connection) UnmarshalJSON(data []byte) error {
+	var v jsonConnection
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*c = Connection{NodeIdx: v.NodeIdx, ParamName: v.ParamName}
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (in *Input) MarshalJSON() ([]byte, error) {
+	v := &jsonInput{Name: in.Name, DataType: in.DataType}
+	if conn := in.Kind.Connection; conn != nil {
+		v.Kind.Connection = &jsonConnection{NodeIdx: conn.NodeIdx, ParamName: conn.ParamName}
+	} else {
+		v.Kind.External = &jsonExternal{}
+		if ext := in.Kind.External; ext != nil {
+			v.Kind.External.Promoted = ext.Promoted
+		}
+	}
+	return json.Marshal(v)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (in *Input) UnmarshalJSON(data []byte) error {
+	var v jsonInput
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*in = Input{Name: v.Name, DataType: v.DataType}
+	switch {
+	case v.Kind.External != nil && v.Kind.Connection != nil:
+		return fmt.Errorf("input %q: kind must be either 'external' or 'connection', not both", v.Name)
+	case v.Kind.Connection != nil:
+		in.Kind.Connection = &Connection{NodeIdx: v.Kind.Connection.NodeIdx, ParamName: v.Kind.Connection.ParamName}
+	case v.Kind.External != nil:
+		in.Kind.External = &External{Promoted: v.Kind.External.Promoted}
+	default:
+		return fmt.Errorf("input %q: missing kind 'external' or 'connection'", v.Name)
+	}
+	return nil
+}
+
+type jsonOutput struct {
+	Name     string `json:"name"`
+	DataType string `json:"data_type"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (out *Output) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&jsonOutput{Name: out.Name, DataType: out.DataType})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (out *Output) UnmarshalJSON(data []byte) error {
+	var v jsonOutput
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*out = Output{Name: v.Name, DataType: v.DataType}
+	return nil
+}
+
+type jsonUIData struct {
+	NodePositions    []*Vec2  `json:"node_positions"`
+	NodeOrder        []uint64 `json:"node_order"`
+	Pan              Vec2     `json:"pan"`
+	Zoom             float64  `json:"zoom"`
+	LockedGizmoNodes []uint64 `json:"locked_gizmo_nodes"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (ui *UIData) MarshalJSON() ([]byte, error) {
+	v := &jsonUIData{
+		NodePositions:    ui.NodePositions,
+		NodeOrder:        ui.NodeOrder,
+		Pan:              ui.Pan,
+		Zoom:             ui.Zoom,
+		LockedGizmoNodes: ui.LockedGizmoNodes,
+	}
+	if v.NodePositions == nil {
+		v.NodePositions = []*Vec2{}
+	}
+	if v.NodeOrder == nil {
+		v.NodeOrder = []uint64{}
+	}
+	if v.LockedGizmoNodes == nil {
+		v.LockedGizmoNodes = []uint64{}
+	}
+	return json.Marshal(v)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (ui *UIData) UnmarshalJSON(data []byte) error {
+	var v jsonUIData
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*ui = UIData{Pan: v.Pan, Zoom: v.Zoom}
+	if len(v.NodePositions) > 0 {
+		ui.NodePositions = v.NodePositions
+	}
+	if len(v.NodeOrder) > 0 {
+		ui.NodeOrder = v.NodeOrder
+	}
+	if len(v.LockedGizmoNodes) > 0 {
+		ui.LockedGizmoNodes = v.LockedGizmoNodes
+	}
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler and encodes the vector as [x, y].
+func (v Vec2) MarshalJSON() ([]byte, error) {
+	return json.Marshal([2]float64{v.X, v.Y})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (v *Vec2) UnmarshalJSON(data []byte) error {
+	var xy [2]float64
+	if err := json.Unmarshal(data, &xy); err != nil {
+		return err
+	}
+	v.X, v.Y = xy[0], xy[1]
+	return nil
+}
+
+type jsonExternalParameters struct {
+	ParamValues []*ParamValue `json:"param_values"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (ep *ExternalParameters) MarshalJSON() ([]byte, error) {
+	pvs := ep.ParamValues
+	if pvs == nil {
+		pvs = []*ParamValue{}
+	}
+	return json.Marshal(&jsonExternalParameters{ParamValues: pvs})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (ep *ExternalParameters) UnmarshalJSON(data []byte) error {
+	var v jsonExternalParameters
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*ep = ExternalParameters{}
+	if len(v.ParamValues) > 0 {
+		ep.ParamValues = v.ParamValues
+	}
+	return nil
+}
+
+type jsonParamValue struct {
+	NodeIdx   uint64    `json:"node_idx"`
+	ParamName string    `json:"param_name"`
+	Value     ValueEnum `json:"value"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (pv *ParamValue) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&jsonParamValue{NodeIdx: pv.NodeIdx, ParamName: pv.ParamName, Value: pv.ValueEnum})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (pv *ParamValue) UnmarshalJSON(data []byte) error {
+	var v jsonParamValue
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*pv = ParamValue{NodeIdx: v.NodeIdx, ParamName: v.ParamName, ValueEnum: v.Value}
+	return nil
+}
+
+// jsonValueEnum is exactly one of its values.
+type jsonValueEnum struct {
+	Scalar    *float64    `json:"scalar,omitempty"`
+	String    *string     `json:"string,omitempty"`
+	Selection *string     `json:"selection,omitempty"`
+	Vector    *[3]float64 `json:"vector,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler and encodes the value as one of:
+// {"scalar": x}, {"string": s}, {"selection": s}, or {"vector": [x, y, z]}.
+func (ev ValueEnum) MarshalJSON() ([]byte, error) {
+	var v jsonValueEnum
+	switch {
+	case ev.Scalar != nil:
+		v.Scalar = &ev.Scalar.X
+	case ev.StrVal != nil:
+		v.String = &ev.StrVal.S
+	case ev.Selection != nil:
+		v.Selection = &ev.Selection.Selection
+	case ev.Vector != nil:
+		v.Vector = &[3]float64{ev.Vector.X, ev.Vector.Y, ev.Vector.Z}
+	}
+	return json.Marshal(&v)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (ev *ValueEnum) UnmarshalJSON(data []byte) error {
+	var v jsonValueEnum
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	*ev = ValueEnum{}
+	var count int
+	if v.Scalar != nil {
+		count++
+		ev.Scalar = &ScalarValue{X: *v.Scalar}
+	}
+	if v.String != nil {
+		count++
+		ev.StrVal = &StringValue{S: *v.String}
+	}
+	if v.Selection != nil {
+		count++
+		ev.Selection = &SelectionValue{Selection: *v.Selection}
+	}
+	if v.Vector != nil {
+		count++
+		ev.Vector = &VectorValue{X: v.Vector[0], Y: v.Vector[1], Z: v.Vector[2]}
+	}
+	if count != 1 {
+		return errors.New("value must have exactly one of 'scalar', 'string', 'selection', or 'vector'")
+	}
+	return nil
+}
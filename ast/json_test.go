@@ -0,0 +1,115 @@
+package ast
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestJSON_RoundTrip(t *testing.T) {
+	dirEntries, err := roundTripFiles.ReadDir("testdata/roundtrip")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{
+		"bifilar-electromagnet.bjk": testFile,
+	}
+	for _, de := range dirEntries {
+		buf, err := roundTripFiles.ReadFile(filepath.Join("testdata/roundtrip", de.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		tests[de.Name()] = string(buf)
+	}
+
+	for name, input := range tests {
+		t.Run(name, func(t *testing.T) {
+			want, err := ParseString(name, input)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			buf, err := json.Marshal(want)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := &BJK{}
+			if err := json.Unmarshal(buf, got); err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("JSON round trip mismatch (-want +got):\n%v", diff)
+			}
+			if diff := cmp.Diff(want.String(), got.String()); diff != "" {
+				t.Errorf("String mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestJSON_Format(t *testing.T) {
+	design := &BJK{
+		Version: Version{Minor: 1},
+		Graph: &Graph{
+			Nodes: []*Node{{
+				OpName:      "MakeBox",
+				ReturnValue: String("out_mesh"),
+				Inputs: []*Input{
+					{Name: "origin", DataType: "BJK_VECTOR", Kind: DependencyKind{External: &External{Promoted: String("o")}}},
+					{Name: "size", DataType: "BJK_VECTOR", Kind: DependencyKind{Connection: &Connection{NodeIdx: 1, ParamName: "v"}}},
+				},
+				Outputs: []*Output{{Name: "out_mesh", DataType: "BJK_MESH"}},
+				Label:   "Box",
+			}},
+			DefaultNode: Uint64(0),
+			UIData:      &UIData{NodePositions: []*Vec2{{X: 1.5, Y: -2}}, NodeOrder: []uint64{0}, Zoom: 1},
+			ExternalParameters: &ExternalParameters{ParamValues: []*ParamValue{
+				{NodeIdx: 0, ParamName: "origin", ValueEnum: ValueEnum{Vector: &VectorValue{X: 1, Y: 2, Z: 3}}},
+				{NodeIdx: 0, ParamName: "s", ValueEnum: ValueEnum{Selection: &SelectionValue{Selection: "*"}}},
+			}},
+		},
+	}
+
+	got, err := json.Marshal(design)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{"version":{"major":0,"minor":1,"patch":0},"graph":{"nodes":[{"op_name":"MakeBox","return_value":"out_mesh","inputs":[{"name":"origin","data_type":"BJK_VECTOR","kind":{"external":{"promoted":"o"}}},{"name":"size","data_type":"BJK_VECTOR","kind":{"connection":{"node_idx":1,"param_name":"v"}}}],"outputs":[{"name":"out_mesh","data_type":"BJK_MESH"}]}],"default_node":0,"ui_data":{"node_positions":[[1.5,-2]],"node_order":[0],"pan":[0,0],"zoom":1,"locked_gizmo_nodes":[]},"external_parameters":{"param_values":[{"node_idx":0,"param_name":"origin","value":{"vector":[1,2,3]}},{"node_idx":0,"param_name":"s","value":{"selection":"*"}}]}}}`
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Errorf("json.Marshal mismatch (-want +got):\n%v", diff)
+	}
+}
+
+func TestJSON_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{
+			name:  "value with two types",
+			input: `{"graph":{"nodes":[],"external_parameters":{"param_values":[{"node_idx":0,"param_name":"x","value":{"scalar":1,"string":"a"}}]}}}`,
+		},
+		{
+			name:  "value with no type",
+			input: `{"graph":{"nodes":[],"external_parameters":{"param_values":[{"node_idx":0,"param_name":"x","value":{}}]}}}`,
+		},
+		{
+			name:  "input with no kind",
+			input: `{"graph":{"nodes":[{"op_name":"MakeBox","inputs":[{"name":"size","data_type":"BJK_VECTOR","kind":{}}]}]}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := json.Unmarshal([]byte(tt.input), &BJK{}); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
@@ -0,0 +1,274 @@
+package ast
+
+import (
+	"sort"
+)
+
+// LayoutOptions controls the spacing used by LayeredLayout.
+// Zero values are replaced by the defaults below.
+type LayoutOptions struct {
+	// LayerSpacing is the horizontal distance between layers.
+	LayerSpacing float64
+	// NodeSpacing is the vertical gap between nodes within a layer.
+	NodeSpacing float64
+	// NodeHeight is the height of a node without any inputs or outputs.
+	NodeHeight float64
+	// RowHeight is the additional height of a node per input or output.
+	RowHeight float64
+	// Sweeps is the number of crossing-reduction passes to make.
+	Sweeps int
+	// NodeWidth is the width of a node, used to keep the other nodes
+	// from overlapping fixed nodes.
+	NodeWidth float64
+	// Fixed, if not nil, holds the positions of the nodes (by index) that
+	// must not move. The other nodes are moved down past any fixed node
+	// that they would overlap.
+	Fixed []*Vec2
+}
+
+const (
+	defaultLayerSpacing = 360
+	defaultNodeSpacing  = 60
+	defaultNodeHeight   = 40
+	defaultRowHeight    = 25
+	defaultSweeps       = 8
+	defaultNodeWidth    = 240
+)
+
+// layoutNode is either a real node of the graph or a dummy node that is
+// inserted wherever an edge spans more than one layer.
+type layoutNode struct {
+	idx     int // index in g.Nodes or -1 for a dummy node
+	layer   int
+	order   float64
+	height  float64
+	ups     []*layoutNode
+	downs   []*layoutNode
+	initial int
+}
+
+// LayeredLayout computes a position for every node in the graph using a
+// layered (Sugiyama-style) layout: data flows from left to right, each node is
+// placed one layer to the right of its right-most upstream node, and the nodes
+// within each layer are ordered to reduce the number of crossing connections.
+// A *CycleError is returned if the graph contains a cycle.
+func (g *Graph) LayeredLayout(opts *LayoutOptions) ([]*Vec2, error) {
+	o := LayoutOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.LayerSpacing == 0 {
+		o.LayerSpacing = defaultLayerSpacing
+	}
+	if o.NodeSpacing == 0 {
+		o.NodeSpacing = defaultNodeSpacing
+	}
+	if o.NodeHeight == 0 {
+		o.NodeHeight = defaultNodeHeight
+	}
+	if o.RowHeight == 0 {
+		o.RowHeight = defaultRowHeight
+	}
+	if o.Sweeps == 0 {
+		o.Sweeps = defaultSweeps
+	}
+	if o.NodeWidth == 0 {
+		o.NodeWidth = defaultNodeWidth
+	}
+	isFixed := func(idx int) bool { return idx >= 0 && idx < len(o.Fixed) && o.Fixed[idx] != nil }
+
+	topo, err := g.TopologicalOrder()
+	if err != nil {
+		return nil, err
+	}
+
+	// Step 1 - assign each node to the layer after its right-most upstream node.
+	lns := make([]*layoutNode, len(g.Nodes))
+	for i, n := range g.Nodes {
+		lns[i] = &layoutNode{
+			idx:     i,
+			height:  o.NodeHeight + o.RowHeight*float64(len(n.Inputs)+len(n.Outputs)),
+			initial: i,
+		}
+	}
+	var numLayers int
+	for _, idx := range topo {
+		ln := lns[idx]
+		for _, up := range g.Upstream(idx) {
+			if l := lns[up].layer + 1; l > ln.layer {
+				ln.layer = l
+			}
+		}
+		if ln.layer+1 > numLayers {
+			numLayers = ln.layer + 1
+		}
+	}
+
+	// Step 2 - connect the nodes, inserting dummy nodes for long edges.
+	layers := make([][]*layoutNode, numLayers)
+	for _, ln := range lns {
+		layers[ln.layer] = append(layers[ln.layer], ln)
+	}
+	for i := range g.Nodes {
+		to := lns[i]
+		for _, up := range g.Upstream(uint64(i)) {
+			from := lns[up]
+			for l := from.layer + 1; l < to.layer; l++ {
+				dummy := &layoutNode{idx: -1, layer: l, initial: len(lns) + l}
+				layers[l] = append(layers[l], dummy)
+				from.downs = append(from.downs, dummy)
+				dummy.ups = append(dummy.ups, from)
+				from = dummy
+			}
+			from.downs = append(from.downs, to)
+			to.ups = append(to.ups, from)
+		}
+	}
+	for _, layer := range layers {
+		for i, ln := range layer {
+			ln.order = float64(i)
+		}
+	}
+
+	// Step 3 - reduce crossings with the barycenter heuristic, keeping the best ordering found.
+	best := snapshotOrder(layers)
+	bestCrossings := countCrossings(layers)
+	for sweep := 0; sweep < o.Sweeps && bestCrossings > 0; sweep++ {
+		if sweep%2 == 0 {
+			for l := 1; l < numLayers; l++ {
+				orderByBarycenter(layers[l], func(ln *layoutNode) []*layoutNode { return ln.ups })
+			}
+		} else {
+			for l := numLayers - 2; l >= 0; l-- {
+				orderByBarycenter(layers[l], func(ln *layoutNode) []*layoutNode { return ln.downs })
+			}
+		}
+		if c := countCrossings(layers); c < bestCrossings {
+			best, bestCrossings = snapshotOrder(layers), c
+		}
+	}
+	for l, layer := range best {
+		layers[l] = layer
+	}
+
+	// Step 4 - assign coordinates, centering each layer vertically.
+	// Fixed nodes keep their positions and take no room in their layers.
+	positions := make([]*Vec2, len(g.Nodes))
+	for l, layer := range layers {
+		var total float64
+		for _, ln := range layer {
+			if !isFixed(ln.idx) {
+				total += ln.height + o.NodeSpacing
+			}
+		}
+		y := -total / 2
+		for _, ln := range layer {
+			if isFixed(ln.idx) {
+				continue
+			}
+			if ln.idx >= 0 {
+				positions[ln.idx] = &Vec2{X: float64(l) * o.LayerSpacing, Y: y}
+			}
+			y += ln.height + o.NodeSpacing
+		}
+	}
+
+	// Step 5 - move the other nodes of each layer down past the fixed nodes they overlap.
+	type box struct {
+		pos    *Vec2
+		height float64
+	}
+	var placed []box
+	for i, pos := range o.Fixed {
+		if i < len(positions) && pos != nil {
+			positions[i] = &Vec2{X: pos.X, Y: pos.Y}
+			placed = append(placed, box{pos: positions[i], height: lns[i].height})
+		}
+	}
+	if len(placed) == 0 {
+		return positions, nil
+	}
+	for _, layer := range layers {
+		var shift float64
+		for _, ln := range layer {
+			if ln.idx < 0 || isFixed(ln.idx) {
+				continue
+			}
+			pos := positions[ln.idx]
+			pos.Y += shift
+			for moved := true; moved; {
+				moved = false
+				for _, b := range placed {
+					if pos.X < b.pos.X+o.NodeWidth && b.pos.X < pos.X+o.NodeWidth && pos.Y < b.pos.Y+b.height && b.pos.Y < pos.Y+ln.height {
+						d := b.pos.Y + b.height + o.NodeSpacing - pos.Y
+						pos.Y += d
+						shift += d
+						moved = true
+					}
+				}
+			}
+			placed = append(placed, box{pos: pos, height: ln.height})
+		}
+	}
+
+	return positions, nil
+}
+
+// orderByBarycenter sorts a layer by the average order of each node's
+// neighbors in the adjacent layer. Nodes without neighbors keep their place.
+func orderByBarycenter(layer []*layoutNode, neighbors func(*layoutNode) []*layoutNode) {
+	keys := make(map[*layoutNode]float64, len(layer))
+	for _, ln := range layer {
+		ns := neighbors(ln)
+		if len(ns) == 0 {
+			keys[ln] = ln.order
+			continue
+		}
+		var sum float64
+		for _, n := range ns {
+			sum += n.order
+		}
+		keys[ln] = sum / float64(len(ns))
+	}
+	sort.SliceStable(layer, func(i, j int) bool {
+		if keys[layer[i]] != keys[layer[j]] {
+			return keys[layer[i]] < keys[layer[j]]
+		}
+		return layer[i].initial < layer[j].initial
+	})
+	for i, ln := range layer {
+		ln.order = float64(i)
+	}
+}
+
+// countCrossings returns the number of edge crossings between adjacent layers.
+func countCrossings(layers [][]*layoutNode) int {
+	var result int
+	for _, layer := range layers {
+		type edge struct{ from, to float64 }
+		var edges []edge
+		for _, ln := range layer {
+			for _, down := range ln.downs {
+				edges = append(edges, edge{from: ln.order, to: down.order})
+			}
+		}
+		for i := range edges {
+			for j := i + 1; j < len(edges); j++ {
+				a, b := edges[i], edges[j]
+				if (a.from < b.from && a.to > b.to) || (a.from > b.from && a.to < b.to) {
+					result++
+				}
+			}
+		}
+	}
+	return result
+}
+
+// snapshotOrder copies the current order of every layer.
+func snapshotOrder(layers [][]*layoutNode) [][]*layoutNode {
+	result := make([][]*layoutNode, len(layers))
+	for l, layer := range layers {
+		result[l] = append([]*layoutNode{}, layer...)
+	}
+	return result
+}
@@ -0,0 +1,130 @@
+package ast
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLayeredLayout_Layers(t *testing.T) {
+	tests := []struct {
+		name string
+		g    *Graph
+		want []float64 // X of each node
+	}{
+		{
+			name: "no nodes",
+			g:    &Graph{},
+			want: []float64{},
+		},
+		{
+			name: "unconnected nodes share the first layer",
+			g:    graphFromEdges(3),
+			want: []float64{0, 0, 0},
+		},
+		{
+			name: "chain",
+			g:    graphFromEdges(3, [2]uint64{0, 1}, [2]uint64{1, 2}),
+			want: []float64{0, 100, 200},
+		},
+		{
+			name: "node follows its right-most upstream node",
+			g:    graphFromEdges(4, [2]uint64{0, 1}, [2]uint64{1, 2}, [2]uint64{0, 3}, [2]uint64{2, 3}),
+			want: []float64{0, 100, 200, 300},
+		},
+		{
+			name: "index order does not matter",
+			g:    graphFromEdges(3, [2]uint64{2, 1}, [2]uint64{1, 0}),
+			want: []float64{200, 100, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			positions, err := tt.g.LayeredLayout(&LayoutOptions{LayerSpacing: 100})
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make([]float64, 0, len(positions))
+			for _, p := range positions {
+				got = append(got, p.X)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("LayeredLayout mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestLayeredLayout_NoOverlap(t *testing.T) {
+	// 0 and 1 feed 2; 0 also feeds 3 and 4; 3 and 4 feed 5.
+	g := graphFromEdges(6, [2]uint64{0, 2}, [2]uint64{1, 2}, [2]uint64{0, 3}, [2]uint64{0, 4}, [2]uint64{3, 5}, [2]uint64{4, 5})
+	opts := &LayoutOptions{NodeSpacing: 10, NodeHeight: 20, RowHeight: 5}
+	positions, err := g.LayeredLayout(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, a := range positions {
+		for j := i + 1; j < len(positions); j++ {
+			b := positions[j]
+			if a.X != b.X {
+				continue
+			}
+			ha := opts.NodeHeight + opts.RowHeight*float64(len(g.Nodes[i].Inputs)+len(g.Nodes[i].Outputs))
+			hb := opts.NodeHeight + opts.RowHeight*float64(len(g.Nodes[j].Inputs)+len(g.Nodes[j].Outputs))
+			if a.Y < b.Y+hb && b.Y < a.Y+ha {
+				t.Errorf("nodes %v at %v and %v at %v overlap", i, *a, j, *b)
+			}
+		}
+	}
+}
+
+func TestLayeredLayout_Fixed(t *testing.T) {
+	// 0 feeds 1 and 2, which are both in the second layer.
+	g := graphFromEdges(3, [2]uint64{0, 1}, [2]uint64{0, 2})
+	opts := &LayoutOptions{NodeSpacing: 10, NodeHeight: 20, RowHeight: 5}
+	free, err := g.LayeredLayout(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Pin node 2 on top of where node 1 would go.
+	opts.Fixed = []*Vec2{nil, nil, {X: free[1].X, Y: free[1].Y}}
+	positions, err := g.LayeredLayout(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(opts.Fixed[2], positions[2]); diff != "" {
+		t.Errorf("fixed node moved (-want +got):\n%v", diff)
+	}
+	h := func(i int) float64 {
+		return opts.NodeHeight + opts.RowHeight*float64(len(g.Nodes[i].Inputs)+len(g.Nodes[i].Outputs))
+	}
+	if a, b := positions[1], positions[2]; a.Y < b.Y+h(2) && b.Y < a.Y+h(1) {
+		t.Errorf("node 1 at %v overlaps fixed node 2 at %v", *a, *b)
+	}
+}
+
+func TestLayeredLayout_ReducesCrossings(t *testing.T) {
+	// In index order, 0→3 and 1→2 cross; the layout should uncross them.
+	g := graphFromEdges(4, [2]uint64{0, 3}, [2]uint64{1, 2})
+	positions, err := g.LayeredLayout(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if (positions[0].Y < positions[1].Y) != (positions[3].Y < positions[2].Y) {
+		t.Errorf("edges cross: positions = %v, %v, %v, %v", *positions[0], *positions[1], *positions[2], *positions[3])
+	}
+}
+
+func TestLayeredLayout_Cycle(t *testing.T) {
+	g := graphFromEdges(2, [2]uint64{0, 1}, [2]uint64{1, 0})
+	_, err := g.LayeredLayout(nil)
+	var cycleErr *CycleError
+	if !errors.As(err, &cycleErr) {
+		t.Fatalf("LayeredLayout err = %v, want *CycleError", err)
+	}
+}
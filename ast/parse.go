@@ -0,0 +1,170 @@
+package ast
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/alecthomas/participle/v2"
+)
+
+// ParseError is a BJK syntax error with enough context to locate and fix it.
+type ParseError struct {
+	Filename string
+	Line     int // 1-based
+	Column   int // 1-based
+	// Message is the unadorned error message from the parser.
+	Message string
+	// Unexpected is the token that was found, if known.
+	Unexpected string
+	// Expected is the grammar element that was expected, if known.
+	Expected string
+	// Excerpt is the line of source containing the error, followed by
+	// a second line with a caret ('^') under the offending column.
+	Excerpt string
+
+	Err error
+}
+
+// Error returns the error in the form "<filename>:<line>:<column>: <message>",
+// followed by the source excerpt (if any).
+func (e *ParseError) Error() string {
+	var pos string
+	if e.Filename != "" {
+		pos = e.Filename + ":"
+	}
+	if e.Line != 0 || e.Column != 0 {
+		pos += fmt.Sprintf("%v:%v:", e.Line, e.Column)
+	}
+	msg := e.Message
+	if pos != "" {
+		msg = pos + " " + msg
+	}
+	if e.Excerpt == "" {
+		return msg
+	}
+	return msg + "\n" + e.Excerpt
+}
+
+// Unwrap returns the underlying parser error.
+func (e *ParseError) Unwrap() error { return e.Err }
+
+// ParseFile reads and parses a BJK design from filename. See ParseString.
+func ParseFile(filename string, opts ...participle.ParseOption) (*BJK, error) {
+	buf, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return ParseString(filename, string(buf), opts...)
+}
+
+// ParseReader reads and parses a BJK design from r. See ParseString.
+// filename is only used in error messages.
+func ParseReader(filename string, r io.Reader, opts ...participle.ParseOption) (*BJK, error) {
+	buf, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return ParseString(filename, string(buf), opts...)
+}
+
+// ParseString parses a BJK design from s and resolves the types of all
+// external parameter values that the grammar alone cannot distinguish.
+// filename is only used in error messages.
+// Syntax errors are returned as a *ParseError.
+func ParseString(filename, s string, opts ...participle.ParseOption) (*BJK, error) {
+	design, err := Parser.ParseString(filename, s, opts...)
+	if err != nil {
+		return nil, newParseError(filename, s, err)
+	}
+
+	design.ResolveSelections()
+	return design, nil
+}
+
+var expectedRE = regexp.MustCompile(`\(expected (.*)\)$`)
+
+// newParseError adds the position, expected element, and source excerpt
+// to a participle error. Other errors are returned unchanged.
+func newParseError(filename, src string, err error) error {
+	var perr participle.Error
+	if !errors.As(err, &perr) {
+		return err
+	}
+
+	pos := perr.Position()
+	result := &ParseError{
+		Filename: filename,
+		Line:     pos.Line,
+		Column:   pos.Column,
+		Message:  perr.Message(),
+		Err:      err,
+	}
+	if pos.Filename != "" {
+		result.Filename = pos.Filename
+	}
+
+	var ute *participle.UnexpectedTokenError
+	if errors.As(err, &ute) {
+		result.Unexpected = ute.Unexpected.Value
+		result.Expected = ute.Expect
+	}
+	if result.Expected == "" {
+		if m := expectedRE.FindStringSubmatch(result.Message); m != nil {
+			result.Expected = m[1]
+		}
+	}
+
+	result.Excerpt = excerpt(src, pos.Line, pos.Column)
+	return result
+}
+
+// excerpt returns the source line with a caret under the column.
+// Tabs are preserved so that the caret lines up in a terminal.
+func excerpt(src string, line, column int) string {
+	lines := strings.Split(src, "\n")
+	if line < 1 || line > len(lines) {
+		return ""
+	}
+	text := strings.TrimRight(lines[line-1], "\r")
+
+	var caret strings.Builder
+	for i, r := range []rune(text) {
+		if i >= column-1 {
+			break
+		}
+		if r == '\t' {
+			caret.WriteRune('\t')
+		} else {
+			caret.WriteRune(' ')
+		}
+	}
+	caret.WriteRune('^')
+	return text + "\n" + caret.String()
+}
+
+// ResolveSelections converts each external parameter StrVal to a Selection
+// when the input it refers to has a data_type of "BJK_SELECTION".
+// It is called automatically by ParseString but may be called again
+// after a graph has been edited.
+func (b *BJK) ResolveSelections() {
+	if b == nil || b.Graph == nil || b.Graph.ExternalParameters == nil {
+		return
+	}
+
+	nodes := b.Graph.Nodes
+	for _, pv := range b.Graph.ExternalParameters.ParamValues {
+		if pv.ValueEnum.StrVal == nil || pv.NodeIdx >= uint64(len(nodes)) {
+			continue
+		}
+		input, ok := nodes[pv.NodeIdx].GetInput(pv.ParamName)
+		if !ok || DataTypeToBJK(input.DataType) != "BJK_SELECTION" {
+			continue
+		}
+		pv.ValueEnum.Selection = &SelectionValue{Selection: pv.ValueEnum.StrVal.S}
+		pv.ValueEnum.StrVal = nil
+	}
+}
@@ -0,0 +1,139 @@
+package ast
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestParseString_Selections(t *testing.T) {
+	const nodes = `nodes: [ ( op_name: "BevelEdges", return_value: Some("out_mesh"), inputs: [
+( name: "edges", data_type: "BJK_SELECTION", kind: External( promoted: None, ), ),
+( name: "mode", data_type: "BJK_STRING", kind: External( promoted: None, ), ), ], outputs: [ ], ), ],`
+
+	tests := []struct {
+		name  string
+		input string
+		want  []ValueEnum
+	}{
+		{
+			name:  "strings resolved by data_type",
+			input: header + "( " + nodes + ` external_parameters: Some(( param_values: { ( node_idx: 0, param_name: "edges", ): String("*"), ( node_idx: 0, param_name: "mode", ): String("Fast"), }, )), )`,
+			want: []ValueEnum{
+				{Selection: &SelectionValue{Selection: "*"}},
+				{StrVal: &StringValue{S: "Fast"}},
+			},
+		},
+		{
+			name:  "legacy Selection syntax",
+			input: header + "( " + nodes + ` external_parameters: Some(( param_values: { ( node_idx: 0, param_name: "edges", ): Selection("1, 2"), }, )), )`,
+			want: []ValueEnum{
+				{Selection: &SelectionValue{Selection: "1, 2"}},
+			},
+		},
+		{
+			name:  "out-of-range node is left alone",
+			input: header + "( " + nodes + ` external_parameters: Some(( param_values: { ( node_idx: 7, param_name: "edges", ): String("*"), }, )), )`,
+			want: []ValueEnum{
+				{StrVal: &StringValue{S: "*"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			design, err := ParseString("", tt.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []ValueEnum
+			for _, pv := range design.Graph.ExternalParameters.ParamValues {
+				got = append(got, pv.ValueEnum)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("ParseString mismatch (-want +got):\n%v", diff)
+			}
+
+			reparsed, err := ParseString("", design.String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(design, reparsed); diff != "" {
+				t.Errorf("re-parsed design mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestParseString_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  *ParseError
+	}{
+		{
+			name:  "unexpected token",
+			input: header + "(\n  nodes: [],\n  default_node: Maybe(0),\n)",
+			want: &ParseError{
+				Filename:   "test.bjk",
+				Line:       4,
+				Column:     17,
+				Unexpected: "Maybe",
+				Expected:   `(("Some" "(" <int> ")") | "None") ","?`,
+				Excerpt:    "  default_node: Maybe(0),\n                ^",
+			},
+		},
+		{
+			name:  "tabs are kept in the excerpt",
+			input: header + "(\n\tnodes: [],\n\tdefault_node: Some(x),\n)",
+			want: &ParseError{
+				Filename:   "test.bjk",
+				Line:       4,
+				Column:     21,
+				Unexpected: "x",
+				Expected:   `<int> ")"`,
+				Excerpt:    "\tdefault_node: Some(x),\n\t                   ^",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseString("test.bjk", tt.input)
+			var got *ParseError
+			if !errors.As(err, &got) {
+				t.Fatalf("ParseString err = %v, want *ParseError", err)
+			}
+			if diff := cmp.Diff(tt.want, got, cmpopts.IgnoreFields(ParseError{}, "Message", "Err")); diff != "" {
+				t.Errorf("ParseString error mismatch (-want +got):\n%v", diff)
+			}
+			if !strings.HasPrefix(got.Error(), fmt.Sprintf("test.bjk:%v:%v: ", tt.want.Line, tt.want.Column)) {
+				t.Errorf("Error() = %q, want position prefix", got.Error())
+			}
+			if !strings.HasSuffix(got.Error(), "\n"+tt.want.Excerpt) {
+				t.Errorf("Error() = %q, want excerpt suffix", got.Error())
+			}
+		})
+	}
+}
+
+func TestParseReader(t *testing.T) {
+	want, err := ParseString("", testFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ParseReader("", strings.NewReader(testFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ParseReader mismatch (-want +got):\n%v", diff)
+	}
+}
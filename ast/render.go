@@ -0,0 +1,120 @@
+package ast
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RenderOptions controls how a Graph is rendered by ToDOT and ToMermaid.
+type RenderOptions struct {
+	// Names optionally overrides the name of each node (by index).
+	// By default, Node.Name is used (e.g. "Helix.wire-1").
+	Names []string
+	// IsDefault optionally reports whether an external parameter value is
+	// the default value for its input, in which case it is not rendered.
+	IsDefault func(nodeIdx uint64, paramName string, ve *ValueEnum) bool
+}
+
+// ToDOT renders the graph in Graphviz DOT format.
+// Each node is labeled with its op name, its optional name, and its
+// (non-default) parameter values. Each edge is labeled "output → input".
+func (g *Graph) ToDOT(opts *RenderOptions) string {
+	lines := []string{
+		"digraph bjk {",
+		indent + "rankdir=LR;",
+		indent + "node [shape=box];",
+	}
+	f := func(fmtStr string, args ...any) { lines = append(lines, fmt.Sprintf(indent+fmtStr, args...)) }
+
+	params := g.paramValuesByKey()
+	for i := range g.Nodes {
+		idx := uint64(i)
+		attrs := fmt.Sprintf("label=%v", dotQuote(strings.Join(g.renderLabel(idx, params, opts), "\n")))
+		if g.DefaultNode != nil && *g.DefaultNode == idx {
+			attrs += ", penwidth=3"
+		}
+		f("n%v [%v];", idx, attrs)
+	}
+
+	g.renderEdges(func(from, to uint64, label string) {
+		f("n%v -> n%v [label=%v];", from, to, dotQuote(label))
+	})
+
+	lines = append(lines, "}")
+	return strings.Join(lines, "\n")
+}
+
+// ToMermaid renders the graph as a Mermaid flowchart.
+// The nodes and edges are labeled the same as ToDOT.
+func (g *Graph) ToMermaid(opts *RenderOptions) string {
+	lines := []string{"flowchart LR"}
+	f := func(fmtStr string, args ...any) { lines = append(lines, fmt.Sprintf(indent+fmtStr, args...)) }
+
+	params := g.paramValuesByKey()
+	for i := range g.Nodes {
+		idx := uint64(i)
+		f("n%v[%v]", idx, mermaidQuote(strings.Join(g.renderLabel(idx, params, opts), "<br/>")))
+	}
+
+	g.renderEdges(func(from, to uint64, label string) {
+		f("n%v -->|%v| n%v", from, mermaidQuote(label), to)
+	})
+
+	if g.DefaultNode != nil && *g.DefaultNode < uint64(len(g.Nodes)) {
+		f("style n%v stroke-width:3px", *g.DefaultNode)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// renderLabel returns the lines of the label for node idx.
+func (g *Graph) renderLabel(idx uint64, params map[string]*ValueEnum, opts *RenderOptions) []string {
+	n := g.Nodes[idx]
+	lines := []string{n.OpName}
+
+	name := n.Name
+	if opts != nil && idx < uint64(len(opts.Names)) {
+		name = opts.Names[idx]
+	}
+	if name = strings.TrimPrefix(name, n.OpName+"."); name != "" && name != n.OpName {
+		lines = append(lines, name)
+	}
+
+	for _, input := range n.Inputs {
+		if input.Kind.Connection != nil {
+			continue
+		}
+		ve, ok := params[genParamKey(idx, input.Name)]
+		if !ok || (opts != nil && opts.IsDefault != nil && opts.IsDefault(idx, input.Name, ve)) {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%v = %v", input.Name, ve))
+	}
+
+	return lines
+}
+
+// renderEdges calls fn for each connection in the graph in node order.
+func (g *Graph) renderEdges(fn func(from, to uint64, label string)) {
+	for i, n := range g.Nodes {
+		for _, input := range n.Inputs {
+			conn := input.Kind.Connection
+			if conn == nil {
+				continue
+			}
+			fn(conn.NodeIdx, uint64(i), fmt.Sprintf("%v → %v", conn.ParamName, input.Name))
+		}
+	}
+}
+
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return `"` + s + `"`
+}
+
+func mermaidQuote(s string) string {
+	s = strings.ReplaceAll(s, `"`, "#quot;")
+	return `"` + s + `"`
+}
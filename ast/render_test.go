@@ -0,0 +1,93 @@
+package ast
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRender(t *testing.T) {
+	g := validGraph()
+	opts := &RenderOptions{
+		Names: []string{"MakeScalar.turns", "Helix"},
+		IsDefault: func(nodeIdx uint64, paramName string, ve *ValueEnum) bool {
+			return paramName == "segments" && ve.Scalar != nil && ve.Scalar.X == 36
+		},
+	}
+
+	tests := []struct {
+		name string
+		fn   func(*RenderOptions) string
+		opts *RenderOptions
+		want []string
+	}{
+		{
+			name: "DOT",
+			fn:   g.ToDOT,
+			opts: opts,
+			want: []string{
+				`digraph bjk {`,
+				`    rankdir=LR;`,
+				`    node [shape=box];`,
+				`    n0 [label="MakeScalar\nturns\nx = Scalar(2.0)"];`,
+				`    n1 [label="Helix", penwidth=3];`,
+				`    n0 -> n1 [label="x → turns"];`,
+				`}`,
+			},
+		},
+		{
+			name: "DOT without options",
+			fn:   g.ToDOT,
+			want: []string{
+				`digraph bjk {`,
+				`    rankdir=LR;`,
+				`    node [shape=box];`,
+				`    n0 [label="MakeScalar\nx = Scalar(2.0)"];`,
+				`    n1 [label="Helix\nsegments = Scalar(36.0)", penwidth=3];`,
+				`    n0 -> n1 [label="x → turns"];`,
+				`}`,
+			},
+		},
+		{
+			name: "Mermaid",
+			fn:   g.ToMermaid,
+			opts: opts,
+			want: []string{
+				`flowchart LR`,
+				`    n0["MakeScalar<br/>turns<br/>x = Scalar(2.0)"]`,
+				`    n1["Helix"]`,
+				`    n0 -->|"x → turns"| n1`,
+				`    style n1 stroke-width:3px`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := strings.Split(tt.fn(tt.opts), "\n")
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("render mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestRender_Quoting(t *testing.T) {
+	g := &Graph{
+		Nodes: []*Node{{
+			OpName: "MakeComment",
+			Inputs: []*Input{{Name: "comment", Kind: DependencyKind{External: &External{}}}},
+		}},
+		ExternalParameters: &ExternalParameters{ParamValues: []*ParamValue{
+			{NodeIdx: 0, ParamName: "comment", ValueEnum: ValueEnum{StrVal: &StringValue{S: "a \"quoted\"\nline"}}},
+		}},
+	}
+
+	if got, want := g.ToDOT(nil), `n0 [label="MakeComment\ncomment = String(\"a \\\"quoted\\\"\\nline\")"];`; !strings.Contains(got, want) {
+		t.Errorf("ToDOT = %v, want to contain %v", got, want)
+	}
+	if got, want := g.ToMermaid(nil), `n0["MakeComment<br/>comment = String(#quot;a \#quot;quoted\#quot;\nline#quot;)"]`; !strings.Contains(got, want) {
+		t.Errorf("ToMermaid = %v, want to contain %v", got, want)
+	}
+}
@@ -2,6 +2,7 @@ package ast
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 )
 
@@ -23,6 +24,10 @@ func (b *BJK) String() string {
 	return fmt.Sprintf("%v %v %v %v\n%v", headerStr, v.Major, v.Minor, v.Patch, b.Graph)
 }
 
+func (v Version) String() string {
+	return fmt.Sprintf("%v.%v.%v", v.Major, v.Minor, v.Patch)
+}
+
 func (g *Graph) String() string {
 	lines := []string{"("}
 	f := func(fmtStr string, args ...any) { lines = append(lines, fmt.Sprintf(indent+fmtStr, args...)) }
@@ -64,6 +69,9 @@ func (n *Node) String() string {
 	lines := []string{"("}
 	f := func(fmtStr string, args ...any) { lines = append(lines, fmt.Sprintf(indent+fmtStr, args...)) }
 
+	if n.Name != "" {
+		f("%v%v", nodeNameStr, n.Name)
+	}
 	f("op_name: %q,", n.OpName)
 
 	if n.ReturnValue != nil {
@@ -93,16 +101,16 @@ func (in *Input) String() string {
 	f := func(fmtStr string, args ...any) { lines = append(lines, fmt.Sprintf(indent+fmtStr, args...)) }
 
 	f("name: %q,", in.Name)
-	f("data_type: %q,", dataTypeToBJK(in.DataType))
+	f("data_type: %q,", DataTypeToBJK(in.DataType))
 
 	if v := in.Kind.Connection; v != nil {
 		f("kind: Conection(") // [sic]
 		f(indent+"node_idx: %v,", v.NodeIdx)
 		f(indent+"param_name: %q,", v.ParamName)
 		f("),")
-	} else if in.Kind.External != nil {
+	} else if v := in.Kind.External; v != nil && v.Promoted != nil {
 		f("kind: External(")
-		// TODO - f(indent + "promoted: None,")
+		f(indent+"promoted: Some(%q),", *v.Promoted)
 		f("),")
 	} else {
 		f("kind: External(")
@@ -119,13 +127,15 @@ func (out *Output) String() string {
 	f := func(fmtStr string, args ...any) { lines = append(lines, fmt.Sprintf(indent+fmtStr, args...)) }
 
 	f("name: %q,", out.Name)
-	f("data_type: %q,", dataTypeToBJK(out.DataType))
+	f("data_type: %q,", DataTypeToBJK(out.DataType))
 
 	lines = append(lines, ")")
 	return strings.Join(lines, "\n")
 }
 
-func dataTypeToBJK(dt string) string {
+// DataTypeToBJK converts a Lua node library data type (e.g. "scalar") to
+// its BJK file equivalent (e.g. "BJK_SCALAR"). BJK data types are returned unchanged.
+func DataTypeToBJK(dt string) string {
 	if v, ok := dataTypeLookup[dt]; ok {
 		return v
 	}
@@ -148,7 +158,7 @@ func (ui *UIData) String() string {
 
 	f("node_positions: [")
 	for _, v2 := range ui.NodePositions {
-		f(indent+"(%0.5f, %0.5f),", v2.X, v2.Y)
+		f(indent+"(%v, %v),", addDot0(v2.X), addDot0(v2.Y))
 	}
 	f("],")
 
@@ -158,9 +168,19 @@ func (ui *UIData) String() string {
 	}
 	f("],")
 
-	f("pan: (%0.5f, %0.5f),", ui.Pan.X, ui.Pan.Y)
-	f("zoom: %0.7f,", ui.Zoom)
-	f("locked_gizmo_nodes: []") // trailing comma added by indentBlock
+	f("pan: (%v, %v),", addDot0(ui.Pan.X), addDot0(ui.Pan.Y))
+	f("zoom: %v,", addDot0(ui.Zoom))
+
+	if len(ui.LockedGizmoNodes) == 0 {
+		f("locked_gizmo_nodes: []") // trailing comma added by indentBlock
+		return strings.Join(lines, "\n")
+	}
+
+	f("locked_gizmo_nodes: [")
+	for _, idx := range ui.LockedGizmoNodes {
+		f(indent+"%v,", idx)
+	}
+	f("]") // trailing comma added by indentBlock
 	return strings.Join(lines, "\n")
 }
 
@@ -216,7 +236,8 @@ func (sv *SelectionValue) String() string {
 	if sv == nil {
 		return ""
 	}
-	return fmt.Sprintf("Selection(%q)", sv.Selection)
+	// Blackjack writes selections using the same syntax as strings.
+	return fmt.Sprintf("String(%q)", sv.Selection)
 }
 
 func (vv *VectorValue) String() string {
@@ -226,8 +247,10 @@ func (vv *VectorValue) String() string {
 	return fmt.Sprintf("Vector((%v, %v, %v))", addDot0(vv.X), addDot0(vv.Y), addDot0(vv.Z))
 }
 
+// addDot0 formats f with the fewest digits necessary to represent it exactly
+// and always includes a decimal point so that the lexer sees a Float token.
 func addDot0(f float64) string {
-	s := fmt.Sprintf("%v", f)
+	s := strconv.FormatFloat(f, 'f', -1, 64)
 	if strings.Contains(s, ".") {
 		return s
 	}
@@ -0,0 +1,53 @@
+package ast
+
+import (
+	"embed"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+//go:embed testdata/roundtrip/*.bjk
+var roundTripFiles embed.FS
+
+func TestRoundTrip(t *testing.T) {
+	dirEntries, err := roundTripFiles.ReadDir("testdata/roundtrip")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{
+		"bifilar-electromagnet.bjk": testFile,
+	}
+	for _, de := range dirEntries {
+		buf, err := roundTripFiles.ReadFile(filepath.Join("testdata/roundtrip", de.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		tests[de.Name()] = string(buf)
+	}
+
+	for name, input := range tests {
+		t.Run(name, func(t *testing.T) {
+			design, err := ParseString(name, input)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, want := design.String(), strings.TrimSuffix(input, "\n")
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("String mismatch (-want +got):\n%v", diff)
+			}
+
+			reparsed, err := ParseString(name, got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(design, reparsed); diff != "" {
+				t.Errorf("re-parsed design mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
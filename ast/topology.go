@@ -0,0 +1,192 @@
+package ast
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// CycleError is returned by TopologicalOrder when the graph contains a cycle.
+type CycleError struct {
+	// Path lists the node indices that form the cycle, starting and
+	// ending with the same node index (e.g. [2, 5, 3, 2]).
+	Path []uint64
+}
+
+func (e *CycleError) Error() string {
+	parts := make([]string, 0, len(e.Path))
+	for _, idx := range e.Path {
+		parts = append(parts, fmt.Sprintf("%v", idx))
+	}
+	return fmt.Sprintf("cycle detected in graph: %v", strings.Join(parts, " -> "))
+}
+
+// Upstream returns the sorted, de-duplicated indices of the nodes that
+// are directly connected to the inputs of node idx.
+// Connections that are out of range are ignored (see Validate).
+func (g *Graph) Upstream(idx uint64) []uint64 {
+	if idx >= uint64(len(g.Nodes)) {
+		return nil
+	}
+
+	seen := map[uint64]bool{}
+	var result []uint64
+	for _, input := range g.Nodes[idx].Inputs {
+		conn := input.Kind.Connection
+		if conn == nil || conn.NodeIdx >= uint64(len(g.Nodes)) || seen[conn.NodeIdx] {
+			continue
+		}
+		seen[conn.NodeIdx] = true
+		result = append(result, conn.NodeIdx)
+	}
+	sortIndices(result)
+	return result
+}
+
+// Downstream returns the sorted indices of the nodes that have at least
+// one input directly connected to an output of node idx.
+func (g *Graph) Downstream(idx uint64) []uint64 {
+	var result []uint64
+	for i := range g.Nodes {
+		for _, up := range g.Upstream(uint64(i)) {
+			if up == idx {
+				result = append(result, uint64(i))
+				break
+			}
+		}
+	}
+	return result
+}
+
+// Dependencies returns the sorted indices of all nodes that node idx
+// transitively depends upon (not including idx itself).
+func (g *Graph) Dependencies(idx uint64) []uint64 {
+	return g.walk(idx, g.Upstream)
+}
+
+// Dependents returns the sorted indices of all nodes that transitively
+// depend upon node idx (not including idx itself).
+func (g *Graph) Dependents(idx uint64) []uint64 {
+	downstream := g.downstreamMap()
+	return g.walk(idx, func(i uint64) []uint64 { return downstream[i] })
+}
+
+func (g *Graph) walk(start uint64, next func(idx uint64) []uint64) []uint64 {
+	seen := map[uint64]bool{start: true}
+	var result []uint64
+	queue := next(start)
+	for len(queue) > 0 {
+		idx := queue[0]
+		queue = queue[1:]
+		if seen[idx] {
+			continue
+		}
+		seen[idx] = true
+		result = append(result, idx)
+		queue = append(queue, next(idx)...)
+	}
+	sortIndices(result)
+	return result
+}
+
+func (g *Graph) downstreamMap() map[uint64][]uint64 {
+	result := map[uint64][]uint64{}
+	for i := range g.Nodes {
+		for _, up := range g.Upstream(uint64(i)) {
+			result[up] = append(result[up], uint64(i))
+		}
+	}
+	return result
+}
+
+// TopologicalOrder returns all node indices ordered such that every node
+// appears after all of the nodes it depends upon. Ties are broken by the
+// lowest node index first so that the result is stable.
+// If the graph contains a cycle, a *CycleError is returned.
+func (g *Graph) TopologicalOrder() ([]uint64, error) {
+	if cycle := g.FindCycle(); cycle != nil {
+		return nil, &CycleError{Path: cycle}
+	}
+
+	numNodes := uint64(len(g.Nodes))
+	inDegree := make([]int, numNodes)
+	for i := range g.Nodes {
+		inDegree[i] = len(g.Upstream(uint64(i)))
+	}
+	downstream := g.downstreamMap()
+
+	var ready []uint64
+	for i, d := range inDegree {
+		if d == 0 {
+			ready = append(ready, uint64(i))
+		}
+	}
+
+	result := make([]uint64, 0, numNodes)
+	for len(ready) > 0 {
+		idx := ready[0]
+		ready = ready[1:]
+		result = append(result, idx)
+		for _, down := range downstream[idx] {
+			inDegree[down]--
+			if inDegree[down] == 0 {
+				ready = append(ready, down)
+				sortIndices(ready)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// FindCycle returns the node indices of the first cycle found in the graph
+// (starting and ending with the same node index) or nil if there is none.
+func (g *Graph) FindCycle() []uint64 {
+	const (
+		unvisited = iota
+		inProgress
+		done
+	)
+	state := make([]int, len(g.Nodes))
+	var stack []uint64
+
+	var visit func(idx uint64) []uint64
+	visit = func(idx uint64) []uint64 {
+		state[idx] = inProgress
+		stack = append(stack, idx)
+		for _, up := range g.Upstream(idx) {
+			switch state[up] {
+			case inProgress:
+				// Report the cycle in the direction that data flows.
+				var path []uint64
+				for i := len(stack) - 1; i >= 0; i-- {
+					path = append(path, stack[i])
+					if stack[i] == up {
+						break
+					}
+				}
+				return append(path, idx)
+			case unvisited:
+				if cycle := visit(up); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[idx] = done
+		return nil
+	}
+
+	for i := range g.Nodes {
+		if state[i] == unvisited {
+			if cycle := visit(uint64(i)); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
+
+func sortIndices(s []uint64) {
+	sort.Slice(s, func(a, b int) bool { return s[a] < s[b] })
+}
@@ -0,0 +1,120 @@
+package ast
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// graphFromEdges creates a graph with numNodes nodes where each edge
+// connects the "out" output of edge[0] to a new input on edge[1].
+func graphFromEdges(numNodes int, edges ...[2]uint64) *Graph {
+	g := &Graph{}
+	for i := 0; i < numNodes; i++ {
+		g.Nodes = append(g.Nodes, &Node{OpName: "Op", Outputs: []*Output{{Name: "out"}}})
+	}
+	for _, e := range edges {
+		to := g.Nodes[e[1]]
+		to.Inputs = append(to.Inputs, &Input{
+			Name: "in",
+			Kind: DependencyKind{Connection: &Connection{NodeIdx: e[0], ParamName: "out"}},
+		})
+	}
+	return g
+}
+
+func TestTopologicalOrder(t *testing.T) {
+	tests := []struct {
+		name      string
+		g         *Graph
+		want      []uint64
+		wantCycle []uint64
+	}{
+		{
+			name: "no nodes",
+			g:    &Graph{},
+			want: []uint64{},
+		},
+		{
+			name: "independent nodes",
+			g:    graphFromEdges(3),
+			want: []uint64{0, 1, 2},
+		},
+		{
+			name: "reverse chain",
+			g:    graphFromEdges(3, [2]uint64{2, 1}, [2]uint64{1, 0}),
+			want: []uint64{2, 1, 0},
+		},
+		{
+			name: "diamond",
+			g:    graphFromEdges(4, [2]uint64{3, 1}, [2]uint64{3, 2}, [2]uint64{1, 0}, [2]uint64{2, 0}),
+			want: []uint64{3, 1, 2, 0},
+		},
+		{
+			name:      "self loop",
+			g:         graphFromEdges(2, [2]uint64{1, 1}),
+			wantCycle: []uint64{1, 1},
+		},
+		{
+			name:      "cycle",
+			g:         graphFromEdges(4, [2]uint64{0, 1}, [2]uint64{1, 2}, [2]uint64{2, 3}, [2]uint64{3, 1}),
+			wantCycle: []uint64{2, 3, 1, 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.g.TopologicalOrder()
+			if tt.wantCycle != nil {
+				var ce *CycleError
+				if !errors.As(err, &ce) {
+					t.Fatalf("TopologicalOrder err = %v, want *CycleError", err)
+				}
+				if diff := cmp.Diff(tt.wantCycle, ce.Path); diff != "" {
+					t.Errorf("CycleError.Path mismatch (-want +got):\n%v", diff)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("TopologicalOrder mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestDependenciesAndDependents(t *testing.T) {
+	// 0 -> 1 -> 3, 2 -> 3, 3 -> 4, 0 -> 4
+	g := graphFromEdges(6, [2]uint64{0, 1}, [2]uint64{1, 3}, [2]uint64{2, 3}, [2]uint64{3, 4}, [2]uint64{0, 4})
+
+	tests := []struct {
+		idx              uint64
+		wantUpstream     []uint64
+		wantDownstream   []uint64
+		wantDependencies []uint64
+		wantDependents   []uint64
+	}{
+		{idx: 0, wantDownstream: []uint64{1, 4}, wantDependents: []uint64{1, 3, 4}},
+		{idx: 3, wantUpstream: []uint64{1, 2}, wantDownstream: []uint64{4}, wantDependencies: []uint64{0, 1, 2}, wantDependents: []uint64{4}},
+		{idx: 4, wantUpstream: []uint64{0, 3}, wantDependencies: []uint64{0, 1, 2, 3}},
+		{idx: 5},
+	}
+
+	for _, tt := range tests {
+		if diff := cmp.Diff(tt.wantUpstream, g.Upstream(tt.idx)); diff != "" {
+			t.Errorf("Upstream(%v) mismatch (-want +got):\n%v", tt.idx, diff)
+		}
+		if diff := cmp.Diff(tt.wantDownstream, g.Downstream(tt.idx)); diff != "" {
+			t.Errorf("Downstream(%v) mismatch (-want +got):\n%v", tt.idx, diff)
+		}
+		if diff := cmp.Diff(tt.wantDependencies, g.Dependencies(tt.idx)); diff != "" {
+			t.Errorf("Dependencies(%v) mismatch (-want +got):\n%v", tt.idx, diff)
+		}
+		if diff := cmp.Diff(tt.wantDependents, g.Dependents(tt.idx)); diff != "" {
+			t.Errorf("Dependents(%v) mismatch (-want +got):\n%v", tt.idx, diff)
+		}
+	}
+}
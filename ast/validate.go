@@ -0,0 +1,170 @@
+package ast
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// These errors are wrapped by ValidationError and can be tested for with errors.Is.
+var (
+	ErrMissingGraph           = errors.New("missing graph")
+	ErrConnectionOutOfRange   = errors.New("connection node_idx out of range")
+	ErrUnknownOutput          = errors.New("connection param_name is not an output of the source node")
+	ErrDefaultNodeOutOfRange  = errors.New("default_node out of range")
+	ErrNodePositionsLength    = errors.New("ui_data node_positions length does not match node count")
+	ErrNodeOrderLength        = errors.New("ui_data node_order length does not match node count")
+	ErrParamValueOutOfRange   = errors.New("external parameter node_idx out of range")
+	ErrUnknownInput           = errors.New("external parameter param_name is not an input of the node")
+	ErrDuplicateParamValue    = errors.New("duplicate external parameter")
+	ErrParamValueOnConnection = errors.New("external parameter points at a connected input")
+)
+
+// ValidationError represents a single structural problem found in a Graph.
+type ValidationError struct {
+	// Err is one of the Err* values above.
+	Err error
+	// NodeIdx is the index of the node that has the problem, if any.
+	NodeIdx *uint64
+	// Name is the name of the input or parameter that has the problem, if any.
+	Name string
+	// Detail provides additional information about the problem.
+	Detail string
+}
+
+func (e *ValidationError) Error() string {
+	var parts []string
+	if e.NodeIdx != nil {
+		parts = append(parts, fmt.Sprintf("node %v", *e.NodeIdx))
+	}
+	if e.Name != "" {
+		parts = append(parts, fmt.Sprintf("%q", e.Name))
+	}
+	parts = append(parts, e.Err.Error())
+	msg := strings.Join(parts, ": ")
+	if e.Detail != "" {
+		msg += " (" + e.Detail + ")"
+	}
+	return msg
+}
+
+// Unwrap returns the underlying Err* value.
+func (e *ValidationError) Unwrap() error { return e.Err }
+
+// ValidationErrors is the list of all problems found by Validate.
+type ValidationErrors []*ValidationError
+
+func (ve ValidationErrors) Error() string {
+	lines := make([]string, 0, len(ve))
+	for _, e := range ve {
+		lines = append(lines, e.Error())
+	}
+	return fmt.Sprintf("%v validation errors:\n%v", len(ve), strings.Join(lines, "\n"))
+}
+
+// Unwrap allows errors.Is and errors.As to inspect each ValidationError.
+func (ve ValidationErrors) Unwrap() []error {
+	errs := make([]error, 0, len(ve))
+	for _, e := range ve {
+		errs = append(errs, e)
+	}
+	return errs
+}
+
+// Validate performs structural checks on the design's graph.
+func (b *BJK) Validate() error {
+	if b == nil {
+		return (*Graph)(nil).Validate()
+	}
+	return b.Graph.Validate()
+}
+
+// Validate performs structural checks on the graph and returns nil
+// or ValidationErrors listing every problem found.
+// A nil graph (e.g. one missing from a decoded design) is reported as ErrMissingGraph.
+func (g *Graph) Validate() error {
+	if g == nil {
+		return ValidationErrors{{Err: ErrMissingGraph}}
+	}
+
+	var errs ValidationErrors
+	add := func(err error, nodeIdx *uint64, name, detailFmt string, args ...any) {
+		errs = append(errs, &ValidationError{
+			Err:     err,
+			NodeIdx: nodeIdx,
+			Name:    name,
+			Detail:  fmt.Sprintf(detailFmt, args...),
+		})
+	}
+
+	numNodes := uint64(len(g.Nodes))
+	for i, n := range g.Nodes {
+		idx := uint64(i)
+		for _, input := range n.Inputs {
+			conn := input.Kind.Connection
+			if conn == nil {
+				continue
+			}
+			if conn.NodeIdx >= numNodes {
+				add(ErrConnectionOutOfRange, &idx, input.Name, "got %v, %v", conn.NodeIdx, validRange(numNodes))
+				continue
+			}
+			src := g.Nodes[conn.NodeIdx]
+			if _, ok := src.GetOutput(conn.ParamName); !ok {
+				add(ErrUnknownOutput, &idx, input.Name, "node %v (%v) has no output %q; valid choices are: %+v", conn.NodeIdx, src.OpName, conn.ParamName, src.GetOutputs())
+			}
+		}
+	}
+
+	if g.DefaultNode != nil && *g.DefaultNode >= numNodes {
+		add(ErrDefaultNodeOutOfRange, nil, "", "got %v, %v", *g.DefaultNode, validRange(numNodes))
+	}
+
+	if ui := g.UIData; ui != nil {
+		if got := len(ui.NodePositions); got != len(g.Nodes) {
+			add(ErrNodePositionsLength, nil, "", "got %v, want %v", got, numNodes)
+		}
+		if got := len(ui.NodeOrder); got != len(g.Nodes) {
+			add(ErrNodeOrderLength, nil, "", "got %v, want %v", got, numNodes)
+		}
+	}
+
+	if ep := g.ExternalParameters; ep != nil {
+		seen := map[string]bool{}
+		for _, pv := range ep.ParamValues {
+			idx := pv.NodeIdx
+			key := fmt.Sprintf("%v,%v", idx, pv.ParamName)
+			if seen[key] {
+				add(ErrDuplicateParamValue, &idx, pv.ParamName, "")
+				continue
+			}
+			seen[key] = true
+
+			if idx >= numNodes {
+				add(ErrParamValueOutOfRange, &idx, pv.ParamName, "%v", validRange(numNodes))
+				continue
+			}
+			n := g.Nodes[idx]
+			input, ok := n.GetInput(pv.ParamName)
+			if !ok {
+				add(ErrUnknownInput, &idx, pv.ParamName, "node %v (%v) valid choices are: %+v", idx, n.OpName, n.GetInputs())
+				continue
+			}
+			if conn := input.Kind.Connection; conn != nil {
+				add(ErrParamValueOnConnection, &idx, pv.ParamName, "connected to node %v output %q", conn.NodeIdx, conn.ParamName)
+			}
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+func validRange(numNodes uint64) string {
+	if numNodes == 0 {
+		return "graph has no nodes"
+	}
+	return fmt.Sprintf("want 0..%v", numNodes-1)
+}
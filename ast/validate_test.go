@@ -0,0 +1,152 @@
+package ast
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func validGraph() *Graph {
+	return &Graph{
+		Nodes: []*Node{
+			{
+				OpName:  "MakeScalar",
+				Inputs:  []*Input{{Name: "x", DataType: "BJK_SCALAR", Kind: DependencyKind{External: &External{}}}},
+				Outputs: []*Output{{Name: "x", DataType: "BJK_SCALAR"}},
+			},
+			{
+				OpName: "Helix",
+				Inputs: []*Input{
+					{Name: "turns", DataType: "BJK_SCALAR", Kind: DependencyKind{Connection: &Connection{NodeIdx: 0, ParamName: "x"}}},
+					{Name: "segments", DataType: "BJK_SCALAR", Kind: DependencyKind{External: &External{}}},
+				},
+				Outputs: []*Output{{Name: "out_mesh", DataType: "BJK_MESH"}},
+			},
+		},
+		DefaultNode: Uint64(1),
+		UIData: &UIData{
+			NodePositions: []*Vec2{{X: 0, Y: 0}, {X: 100, Y: 0}},
+			NodeOrder:     []uint64{0, 1},
+			Zoom:          1,
+		},
+		ExternalParameters: &ExternalParameters{
+			ParamValues: []*ParamValue{
+				{NodeIdx: 0, ParamName: "x", ValueEnum: ValueEnum{Scalar: &ScalarValue{X: 2}}},
+				{NodeIdx: 1, ParamName: "segments", ValueEnum: ValueEnum{Scalar: &ScalarValue{X: 36}}},
+			},
+		},
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(g *Graph)
+		want   []error
+	}{
+		{
+			name:   "valid graph",
+			modify: func(g *Graph) {},
+		},
+		{
+			name:   "connection out of range",
+			modify: func(g *Graph) { g.Nodes[1].Inputs[0].Kind.Connection.NodeIdx = 5 },
+			want:   []error{ErrConnectionOutOfRange},
+		},
+		{
+			name:   "unknown output",
+			modify: func(g *Graph) { g.Nodes[1].Inputs[0].Kind.Connection.ParamName = "y" },
+			want:   []error{ErrUnknownOutput},
+		},
+		{
+			name:   "default node out of range",
+			modify: func(g *Graph) { g.DefaultNode = Uint64(2) },
+			want:   []error{ErrDefaultNodeOutOfRange},
+		},
+		{
+			name: "ui data lengths",
+			modify: func(g *Graph) {
+				g.UIData.NodePositions = g.UIData.NodePositions[:1]
+				g.UIData.NodeOrder = append(g.UIData.NodeOrder, 2)
+			},
+			want: []error{ErrNodePositionsLength, ErrNodeOrderLength},
+		},
+		{
+			name: "duplicate param value",
+			modify: func(g *Graph) {
+				ep := g.ExternalParameters
+				ep.ParamValues = append(ep.ParamValues, &ParamValue{NodeIdx: 0, ParamName: "x", ValueEnum: ValueEnum{Scalar: &ScalarValue{X: 3}}})
+			},
+			want: []error{ErrDuplicateParamValue},
+		},
+		{
+			name: "param value out of range and unknown input",
+			modify: func(g *Graph) {
+				ep := g.ExternalParameters
+				ep.ParamValues = append(ep.ParamValues,
+					&ParamValue{NodeIdx: 9, ParamName: "x", ValueEnum: ValueEnum{Scalar: &ScalarValue{X: 3}}},
+					&ParamValue{NodeIdx: 1, ParamName: "bogus", ValueEnum: ValueEnum{Scalar: &ScalarValue{X: 3}}})
+			},
+			want: []error{ErrParamValueOutOfRange, ErrUnknownInput},
+		},
+		{
+			name: "param value on connected input",
+			modify: func(g *Graph) {
+				ep := g.ExternalParameters
+				ep.ParamValues = append(ep.ParamValues, &ParamValue{NodeIdx: 1, ParamName: "turns", ValueEnum: ValueEnum{Scalar: &ScalarValue{X: 3}}})
+			},
+			want: []error{ErrParamValueOnConnection},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := validGraph()
+			tt.modify(g)
+			err := (&BJK{Graph: g}).Validate()
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Fatalf("Validate = %v, want nil", err)
+				}
+				return
+			}
+
+			var ve ValidationErrors
+			if !errors.As(err, &ve) {
+				t.Fatalf("Validate = %T, want ValidationErrors", err)
+			}
+			var got []error
+			for _, e := range ve {
+				got = append(got, e.Err)
+			}
+			if diff := cmp.Diff(tt.want, got, cmp.Comparer(func(a, b error) bool { return a == b })); diff != "" {
+				t.Errorf("Validate mismatch (-want +got):\n%v", diff)
+			}
+			for _, want := range tt.want {
+				if !errors.Is(err, want) {
+					t.Errorf("errors.Is(%v) = false, want true", want)
+				}
+			}
+		})
+	}
+}
+
+func TestValidate_MissingGraph(t *testing.T) {
+	for _, err := range []error{(*Graph)(nil).Validate(), (&BJK{}).Validate()} {
+		var ve ValidationErrors
+		if !errors.As(err, &ve) || len(ve) != 1 || !errors.Is(err, ErrMissingGraph) {
+			t.Errorf("Validate = %v, want ErrMissingGraph", err)
+		}
+	}
+}
+
+func TestValidate_TestFile(t *testing.T) {
+	design, err := ParseString("", testFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := design.Validate(); err != nil {
+		t.Error(err)
+	}
+}
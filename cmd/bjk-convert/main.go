@@ -0,0 +1,90 @@
+// -*- compile-command: "go run main.go -o - ../../ast/testdata/bifilar-electromagnet.bjk"; -*-
+
+// bjk-convert converts Blackjack BJK files to JSON and back again.
+// The direction of the conversion is determined by the input file's extension:
+// "*.bjk" files are written as "*.json" and "*.json" files are written as "*.bjk".
+// YAML is not supported, but YAML 1.2 parsers can read the JSON output directly.
+// See: https://github.com/setzer22/blackjack
+//
+// Usage:
+//
+//	bjk-convert file.bjk|file.json [file2.bjk|file2.json ...]
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gmlewis/go-bjk/ast"
+)
+
+var (
+	outFile = flag.String("o", "", "Override output filename ('-' for stdout)")
+)
+
+func main() {
+	flag.Parse()
+
+	if *outFile != "" && flag.NArg() > 1 {
+		log.Fatalf("-o can only be used with a single input file")
+	}
+
+	for _, arg := range flag.Args() {
+		processFile(arg)
+	}
+
+	if *outFile != "-" {
+		log.Printf("Done.")
+	}
+}
+
+func processFile(arg string) {
+	buf, err := os.ReadFile(arg)
+	must(err)
+
+	var out []byte
+	var outFilename string
+	switch ext := filepath.Ext(arg); ext {
+	case ".bjk":
+		design, err := ast.ParseString(arg, string(buf))
+		if err != nil {
+			log.Fatalf("ERROR: %v", err)
+		}
+		out, err = json.MarshalIndent(design, "", "  ")
+		must(err)
+		outFilename = strings.TrimSuffix(arg, ext) + ".json"
+	case ".json":
+		design := &ast.BJK{}
+		if err := json.Unmarshal(buf, design); err != nil {
+			log.Fatalf("ERROR: json.Unmarshal(%v): %v", arg, err)
+		}
+		if err := design.Validate(); err != nil {
+			log.Printf("WARNING: %v: %v", arg, err)
+		}
+		out = []byte(design.String())
+		outFilename = strings.TrimSuffix(arg, ext) + ".bjk"
+	default:
+		log.Fatalf("unknown file extension %q, want .bjk or .json", ext)
+	}
+
+	if *outFile == "-" {
+		fmt.Printf("%s\n", out)
+		return
+	}
+	if *outFile != "" {
+		outFilename = *outFile
+	}
+	log.Printf("Writing file: %v", outFilename)
+	must(os.WriteFile(outFilename, append(out, '\n'), 0644))
+}
+
+func must(err error) {
+	if err != nil {
+		log.Fatal(err)
+	}
+}
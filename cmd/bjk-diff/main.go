@@ -0,0 +1,65 @@
+// -*- compile-command: "go run main.go ../../ast/testdata/bifilar-electromagnet.bjk ../../nodes/testdata/bifilar-electromagnet.bjk"; -*-
+
+// bjk-diff reports the semantic differences between two Blackjack BJK files.
+// Nodes are matched by op name and connectivity rather than by index, so
+// inserting or removing a node does not make the rest of the design differ.
+// See: https://github.com/setzer22/blackjack
+//
+// The exit status is 0 if the designs are the same, 1 if they differ.
+//
+// Usage:
+//
+//	bjk-diff [-json] a.bjk b.bjk
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/gmlewis/go-bjk/ast"
+)
+
+var (
+	jsonOut = flag.Bool("json", false, "Write the differences as JSON")
+)
+
+func main() {
+	flag.Parse()
+
+	if flag.NArg() != 2 {
+		log.Fatalf("usage: bjk-diff [-json] a.bjk b.bjk")
+	}
+
+	a := parseFile(flag.Arg(0))
+	b := parseFile(flag.Arg(1))
+	diff := ast.Diff(a, b)
+
+	if *jsonOut {
+		buf, err := json.MarshalIndent(diff, "", "  ")
+		must(err)
+		fmt.Printf("%s\n", buf)
+	} else if !diff.Empty() {
+		fmt.Printf("--- %v\n+++ %v\n%v\n", flag.Arg(0), flag.Arg(1), diff)
+	}
+
+	if !diff.Empty() {
+		os.Exit(1)
+	}
+}
+
+func parseFile(filename string) *ast.BJK {
+	design, err := ast.ParseFile(filename)
+	if err != nil {
+		log.Fatalf("ERROR: %v", err)
+	}
+	return design
+}
+
+func must(err error) {
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,49 @@
+// -*- compile-command: "go run main.go -o ../../typed/nodes_gen.go"; -*-
+
+// bjk-gen-typed reads the node library from the Blackjack repo and writes
+// a Go package that provides a typed API for adding every node to a
+// nodes.Builder, so that mistakes in node names, input names, and value
+// types are caught at compile time.
+// See: https://github.com/setzer22/blackjack
+//
+// Usage:
+//
+//	bjk-gen-typed [-pkg typed] [-o nodes_gen.go]
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"github.com/gmlewis/go-bjk/nodes"
+)
+
+var (
+	debug   = flag.Bool("debug", false, "Turn on debugging info")
+	outFile = flag.String("o", "nodes_gen.go", "Output filename")
+	pkgName = flag.String("pkg", "typed", "Name of the generated Go package")
+	repoDir = flag.String("repo", "src/github.com/gmlewis/blackjack", "Path to Blackjack repo (relative to home dir or absolute path)")
+)
+
+func main() {
+	flag.Parse()
+
+	c, err := nodes.New(*repoDir, *debug)
+	must(err)
+	defer c.Close()
+
+	src, err := c.GenerateGo(*pkgName)
+	must(err)
+
+	log.Printf("Writing Go file: %v", *outFile)
+	must(os.WriteFile(*outFile, src, 0644))
+
+	log.Printf("Done.")
+}
+
+func must(err error) {
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,120 @@
+// -*- compile-command: "go run main.go ../../nodes/testdata/bifilar-electromagnet.bjk"; -*-
+
+// bjk-migrate checks Blackjack BJK files against the node library in the
+// Blackjack repo and reports node inputs and outputs that have since been
+// renamed, removed, added, or retyped. With -fix, the files are updated.
+// See: https://github.com/setzer22/blackjack
+//
+// Renames are only fixed when they are listed in the -migrations file, a JSON
+// array of renames that apply to designs older than their "before" version:
+//
+//	[{"before": {"major": 0, "minor": 2, "patch": 0}, "op_name": "Helix", "from": "size", "to": "height"}]
+//
+// Unambiguous renames that are not listed are reported as "(inferred)" but
+// are left for you to confirm by adding them to the -migrations file.
+//
+// Usage:
+//
+//	bjk-migrate [-fix] [-migrations renames.json] file.bjk [file2.bjk ...]
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/gmlewis/go-bjk/ast"
+	"github.com/gmlewis/go-bjk/nodes"
+)
+
+var (
+	debug      = flag.Bool("debug", false, "Turn on debugging info")
+	fix        = flag.Bool("fix", false, "Fix the issues found and rewrite the file(s)")
+	migrations = flag.String("migrations", "", "JSON file listing port renames (see nodes.Migration)")
+	outFile    = flag.String("o", "", "With -fix, override output filename ('-' for stdout)")
+	repoDir    = flag.String("repo", "src/github.com/gmlewis/blackjack", "Path to Blackjack repo (relative to home dir or absolute path)")
+)
+
+func main() {
+	flag.Parse()
+
+	if *outFile != "" && flag.NArg() > 1 {
+		log.Fatalf("-o can only be used with a single input file")
+	}
+
+	c, err := nodes.New(*repoDir, *debug)
+	must(err)
+	defer c.Close()
+
+	if *migrations != "" {
+		buf, err := os.ReadFile(*migrations)
+		must(err)
+		must(json.Unmarshal(buf, &c.Migrations))
+	}
+
+	var unresolved int
+	for _, arg := range flag.Args() {
+		unresolved += processFile(c, arg)
+	}
+
+	if unresolved > 0 {
+		log.Printf("%v unresolved issue(s) found.", unresolved)
+		os.Exit(1)
+	}
+	if *outFile != "-" {
+		log.Printf("Done.")
+	}
+}
+
+// processFile returns the number of issues that remain unresolved.
+func processFile(c *nodes.Client, arg string) int {
+	design, err := ast.ParseFile(arg)
+	if err != nil {
+		log.Fatalf("ERROR: %v", err)
+	}
+
+	if !*fix {
+		issues := c.CheckDesign(design)
+		for _, issue := range issues {
+			fmt.Printf("%v: %v\n", arg, issue)
+		}
+		return len(issues)
+	}
+
+	var unresolved int
+	issues := c.Migrate(design)
+	for _, issue := range issues {
+		if !issue.Fixed {
+			unresolved++
+		}
+		log.Printf("%v: %v", arg, issue)
+	}
+	if len(issues) == 0 {
+		return 0
+	}
+
+	if err := design.Validate(); err != nil {
+		log.Printf("WARNING: %v: %v", arg, err)
+	}
+
+	out := design.String() + "\n"
+	outFilename := arg
+	if *outFile != "" {
+		outFilename = *outFile
+	}
+	if outFilename == "-" {
+		fmt.Print(out)
+		return unresolved
+	}
+	log.Printf("Writing file: %v", outFilename)
+	must(os.WriteFile(outFilename, []byte(out), 0644))
+	return unresolved
+}
+
+func must(err error) {
+	if err != nil {
+		log.Fatal(err)
+	}
+}
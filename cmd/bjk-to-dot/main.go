@@ -0,0 +1,91 @@
+// -*- compile-command: "go run main.go -o - ../../ast/testdata/bifilar-electromagnet.bjk"; -*-
+
+// bjk-to-dot loads a Blackjack BJK file and writes a Graphviz DOT file
+// (or Mermaid flowchart) of its nodes and connections.
+// If the Blackjack repo can be loaded, parameters that have their
+// default values are omitted from the node labels.
+// See: https://github.com/setzer22/blackjack
+//
+// Usage:
+//
+//	bjk-to-dot [-mermaid] file.bjk [file2.bjk ...]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/gmlewis/go-bjk/ast"
+	"github.com/gmlewis/go-bjk/nodes"
+)
+
+var (
+	debug   = flag.Bool("debug", false, "Turn on debugging info")
+	mermaid = flag.Bool("mermaid", false, "Write a Mermaid flowchart (*.mmd) instead of Graphviz DOT (*.dot)")
+	outFile = flag.String("o", "", "Override output filename ('-' for stdout)")
+	repoDir = flag.String("repo", "src/github.com/gmlewis/blackjack", "Path to Blackjack repo (relative to home dir or absolute path)")
+)
+
+func main() {
+	flag.Parse()
+
+	if *outFile != "" && flag.NArg() > 1 {
+		log.Fatalf("-o can only be used with a single input file")
+	}
+
+	c, err := nodes.New(*repoDir, *debug)
+	if err != nil {
+		log.Printf("WARNING: unable to load Blackjack nodes; showing all parameter values: %v", err)
+		c = nil
+	} else {
+		defer c.Close()
+	}
+
+	for _, arg := range flag.Args() {
+		processFile(c, arg)
+	}
+
+	if *outFile != "-" {
+		log.Printf("Done.")
+	}
+}
+
+func processFile(c *nodes.Client, arg string) {
+	design, err := ast.ParseFile(arg)
+	if err != nil {
+		log.Fatalf("ERROR: %v", err)
+	}
+	if design.Graph == nil {
+		log.Fatalf("ERROR: %v: design has no graph", arg)
+	}
+
+	var opts *ast.RenderOptions
+	if c != nil {
+		opts = c.RenderOptions(design.Graph)
+	}
+
+	out, ext := design.Graph.ToDOT(opts), ".dot"
+	if *mermaid {
+		out, ext = design.Graph.ToMermaid(opts), ".mmd"
+	}
+
+	if *outFile == "-" {
+		fmt.Printf("%v\n", out)
+		return
+	}
+	outFilename := strings.Replace(arg, ".bjk", ext, -1)
+	if *outFile != "" {
+		outFilename = *outFile
+	}
+	log.Printf("Writing file: %v", outFilename)
+	must(os.WriteFile(outFilename, []byte(out+"\n"), 0644))
+}
+
+func must(err error) {
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -46,15 +46,13 @@ type clientT struct {
 }
 
 func (c *clientT) processFile(arg string) {
-	buf, err := os.ReadFile(arg)
-	must(err)
 	var opts []participle.ParseOption
 	if *debug {
 		opts = append(opts, participle.Trace(os.Stderr))
 	}
-	design, err := ast.Parser.ParseString("", string(buf), opts...)
+	design, err := ast.ParseFile(arg, opts...)
 	if err != nil {
-		log.Fatalf("ERROR: ast.Parser.ParseString: %v", err)
+		log.Fatalf("ERROR: %v", err)
 	}
 	outFilename := strings.Replace(arg, ".bjk", ".obj", -1)
 	if *outFile != "" {
@@ -44,14 +44,14 @@ type clientT struct {
 }
 
 func (c *clientT) processFile(arg string) {
-	buf, err := os.ReadFile(arg)
-	must(err)
 	var opts []participle.ParseOption
 	if *debug {
 		opts = append(opts, participle.Trace(os.Stderr))
 	}
-	design, err := ast.Parser.ParseString("", string(buf), opts...)
-	must(err)
+	design, err := ast.ParseFile(arg, opts...)
+	if err != nil {
+		log.Fatalf("ERROR: %v", err)
+	}
 	outFilename := strings.Replace(arg, ".bjk", ".stl", -1)
 	log.Printf("Writing STL file: %v", outFilename)
 	must(c.c.ToSTL(design, outFilename, *swapYZ))
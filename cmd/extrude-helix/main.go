@@ -45,8 +45,8 @@ func main() {
 
 	design, err := c.NewBuilder().
 		AddNode("MakeQuad.1", "normal=vector(0,0,1)").
-		AddNode("Helix.1", setv("size", sx, sy, sz), set("segments", *numSegs), set("turns", *vertTurns)).
-		AddNode("Helix.2", setv("size", sx, sy, sz), set("segments", *numSegs), set("turns", *vertTurns), "start_angle=180").
+		AddNode("Helix.1", nodes.Vector("size", nodes.Vec3{X: sx, Y: sy, Z: sz}), nodes.Scalar("segments", float64(*numSegs)), nodes.Scalar("turns", *vertTurns)).
+		AddNode("Helix.2", nodes.Vector("size", nodes.Vec3{X: sx, Y: sy, Z: sz}), nodes.Scalar("segments", float64(*numSegs)), nodes.Scalar("turns", *vertTurns), "start_angle=180").
 		AddNode("ExtrudeAlongCurve.1", "flip=1").
 		AddNode("ExtrudeAlongCurve.2", "flip=1").
 		Connect("MakeQuad.1.out_mesh", "ExtrudeAlongCurve.1.cross_section").
@@ -76,14 +76,6 @@ func main() {
 	log.Printf("Done.")
 }
 
-func set(key string, value any) string {
-	return fmt.Sprintf("%v=%v", key, value)
-}
-
-func setv(key string, x, y, z any) string {
-	return fmt.Sprintf("%v=vector(%v,%v,%v)", key, x, y, z)
-}
-
 func must(err error) {
 	if err != nil {
 		log.Fatal(err)
@@ -36,7 +36,7 @@ func main() {
 
 	design, err := c.NewBuilder().
 		AddNode("MakeQuad.1").
-		AddNode("ExtrudeFacesWithCaps.1", set("amount", *amount), "faces=*").
+		AddNode("ExtrudeFacesWithCaps.1", nodes.Scalar("amount", *amount), "faces=*").
 		Connect("MakeQuad.1.out_mesh", "ExtrudeFacesWithCaps.1.in_mesh").
 		Build()
 	must(err)
@@ -58,10 +58,6 @@ func main() {
 	log.Printf("Done.")
 }
 
-func set(key string, value any) string {
-	return fmt.Sprintf("%v=%v", key, value)
-}
-
 func must(err error) {
 	if err != nil {
 		log.Fatal(err)
@@ -34,7 +34,7 @@ func main() {
 	must(err)
 	defer c.Close()
 
-	design, err := c.NewBuilder().AddNode("BFEMCage", set("segments", *segments)).Build()
+	design, err := c.NewBuilder().AddNode("BFEMCage", nodes.Scalar("segments", float64(*segments))).Build()
 	must(err)
 
 	if *outBJK == "-" {
@@ -54,10 +54,6 @@ func main() {
 	log.Printf("Done.")
 }
 
-func set(key string, value any) string {
-	return fmt.Sprintf("%v=%v", key, value)
-}
-
 func must(err error) {
 	if err != nil {
 		log.Fatal(err)
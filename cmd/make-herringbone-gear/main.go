@@ -39,15 +39,15 @@ func main() {
 
 	design, err := c.NewBuilder().AddNode(
 		"HerringboneGear",
-		set("gear_length", *gearLength),
-		set("helix_angle", *helixAngle),
-		set("hole_radius", *holeRadius),
-		set("hole_type", *holeType),
-		set("module", *module),
-		set("num_elbows", *numElbows),
-		set("num_teeth", *numTeeth),
-		set("pivot", *pivot),
-		set("resolution", *resolution),
+		nodes.Scalar("gear_length", *gearLength),
+		nodes.Scalar("helix_angle", *helixAngle),
+		nodes.Scalar("hole_radius", *holeRadius),
+		nodes.Enum("hole_type", *holeType),
+		nodes.Scalar("module", *module),
+		nodes.Scalar("num_elbows", float64(*numElbows)),
+		nodes.Scalar("num_teeth", float64(*numTeeth)),
+		nodes.Enum("pivot", *pivot),
+		nodes.Scalar("resolution", float64(*resolution)),
 	).Build()
 	must(err)
 
@@ -81,10 +81,6 @@ func main() {
 	log.Printf("Done.")
 }
 
-func set(key string, value any) string {
-	return fmt.Sprintf("%v=%v", key, value)
-}
-
 func must(err error) {
 	if err != nil {
 		log.Fatal(err)
@@ -46,7 +46,7 @@ func main() {
 	must(err)
 	defer c.Close()
 
-	design, err := c.NewBuilder().AddNode("SVGPath", set("d", *dPath)).Build()
+	design, err := c.NewBuilder().AddNode("SVGPath", nodes.Str("d", *dPath)).Build()
 	must(err)
 
 	if *outBJK == "-" {
@@ -66,10 +66,6 @@ func main() {
 	log.Printf("Done.")
 }
 
-func set(key string, value any) string {
-	return fmt.Sprintf("%v=%v", key, value)
-}
-
 func must(err error) {
 	if err != nil {
 		log.Fatal(err)
@@ -14,6 +14,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
 
 	"github.com/gmlewis/go-bjk/nodes"
 )
@@ -124,26 +125,34 @@ func main() {
 
 	lastSizeOut := "VectorMath.vert-gap.out"
 	nodePosDY = 600
-	for i := 2; i <= *numPairs; i++ {
-		pairName := fmt.Sprintf("pair-%v", i)
-		sizeMathNode := fmt.Sprintf("VectorMath.size-%v", pairName)
-		thisCoilPair := fmt.Sprintf("CoilPair.%v", pairName)
-		b = b.
-			// second instance of CoilPair
-			AddNode("MakeComment", nextNodePos(), fmt.Sprintf("comment=This is coil pair #%v:", i)).
-			AddNode(sizeMathNode).
-			Connect("WireWidthAndGap.1.vxz", sizeMathNode+".vec_b").
-			Connect(lastSizeOut, sizeMathNode+".vec_a").
-			AddNode(thisCoilPair, set("delta_y", float64(i-1)/float64(*numPairs-1)), set("start_angle", 180.0*float64(i-1)/float64(*numPairs))).
-			Connect("MakeScalar.vert-turns.x", thisCoilPair+".turns").
-			Connect("MakeScalar.segments.x", thisCoilPair+".segments").
-			Connect(sizeMathNode+".out", thisCoilPair+".size").
-			Connect("SizedQuad.wire-outline.out_mesh", thisCoilPair+".cross_section").
-			Connect("SizedQuad.wire-outline.wire-width", thisCoilPair+".wire_width").
-			Connect("WireGaps.wire-gap.wire_gap", thisCoilPair+".wire_gap").
-			MergeMesh(thisCoilPair + ".out_mesh")
-		lastSizeOut = sizeMathNode + ".out"
+	sizeMathNode := func(coilPair nodes.NodeRef) string {
+		return "VectorMath.size-" + strings.TrimPrefix(coilPair.Name, "CoilPair.")
 	}
+	// coil pair #1 was added above, so the rest are numbered from #2.
+	b = b.Repeat(*numPairs-1, "CoilPair", func(i int) []any {
+		n := float64(i + 1)
+		return []any{set("delta_y", n/float64(*numPairs-1)), set("start_angle", 180.0*n/float64(*numPairs))}
+	},
+		nodes.RepeatLabel("pair"),
+		nodes.RepeatStart(2),
+		nodes.RepeatBefore(func(b *nodes.Builder, i int, coilPair nodes.NodeRef) *nodes.Builder {
+			return b.
+				AddNode("MakeComment", nextNodePos(), fmt.Sprintf("comment=This is coil pair #%v:", i+2)).
+				AddNode(sizeMathNode(coilPair)).
+				Connect("WireWidthAndGap.1.vxz", sizeMathNode(coilPair)+".vec_b").
+				Connect(lastSizeOut, sizeMathNode(coilPair)+".vec_a")
+		}),
+		nodes.RepeatEach(func(b *nodes.Builder, i int, coilPair nodes.NodeRef) *nodes.Builder {
+			lastSizeOut = sizeMathNode(coilPair) + ".out"
+			return b.Connect(lastSizeOut, coilPair.In("size"))
+		}),
+		nodes.RepeatConnect("MakeScalar.vert-turns.x", "turns"),
+		nodes.RepeatConnect("MakeScalar.segments.x", "segments"),
+		nodes.RepeatConnect("SizedQuad.wire-outline.out_mesh", "cross_section"),
+		nodes.RepeatConnect("SizedQuad.wire-outline.wire-width", "wire_width"),
+		nodes.RepeatConnect("WireGaps.wire-gap.wire_gap", "wire_gap"),
+		nodes.RepeatMerge("out_mesh"),
+	)
 
 	var addInnerSupport int
 	if *addSupport {
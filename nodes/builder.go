@@ -30,6 +30,17 @@ type Builder struct {
 	lastMergeMesh           string
 	groupFullInputPortNames map[string]bool
 
+	// parent is the builder in which a group was defined. Group types used
+	// within a group are looked up in the group and then in its ancestors.
+	parent *Builder
+	// groupInstances maps the full name of each instance of a group
+	// (including nested instances) to the group's definition.
+	groupInstances map[string]*Builder
+	// params maps the name of each design-level parameter (see Param) to its output port.
+	params map[string]PortRef
+	// uiState is the view state of a design loaded by NewBuilderFromBJK.
+	uiState *uiState
+
 	Nodes     map[string]*ast.Node
 	NodeOrder []string
 	Groups    map[string]*Builder
@@ -39,11 +50,58 @@ type Builder struct {
 	InputsAlreadyConnected map[string]string
 
 	CheckUnusedGroupInputs bool
+
+	// Layout selects how Build positions nodes that have no explicit 'node_position'.
+	Layout LayoutMode
+
+	// Constraints selects how values that violate an input's declared
+	// constraints are handled. It defaults to the Client's Constraints.
+	Constraints ConstraintPolicy
+
+	// DefaultNode, if set, is the name of the node that Build makes the
+	// design's default (active) node. Otherwise, the last node is used.
+	DefaultNode string
 }
 
+// LayoutMode selects how Build positions the nodes of a design.
+type LayoutMode int
+
+const (
+	// LayoutDiagonal places each node one step down and to the right of the previous node.
+	LayoutDiagonal LayoutMode = iota
+	// LayoutLayered arranges the nodes in layers from left to right following
+	// their connections, ordering the nodes in each layer to minimize crossings.
+	// Nodes with an explicit 'node_position' stay put and the others avoid them.
+	LayoutLayered
+)
+
 type recorder struct {
-	action string
-	args   []string
+	action   string
+	node     string        // the node of an "AddNode" action
+	nodeArgs []Arg         // the args of an "AddNode" action
+	from     PortRef       // the 'from' port of a "Connect" or "Output" action
+	to       PortRef       // the 'to' port of a "Connect" or "Input" action
+	port     string        // the group's port of an "Input" or "Output" action
+	input    *inputOptions // the options of an "Input" action
+}
+
+// String returns the recorded call, e.g. `Input("angle", "Helix.h.start_angle")`.
+func (r *recorder) String() string {
+	switch r.action {
+	case "AddNode":
+		args := []string{fmt.Sprintf("%q", r.node)}
+		for _, arg := range r.nodeArgs {
+			args = append(args, fmt.Sprintf("%q", arg))
+		}
+		return fmt.Sprintf("AddNode(%v)", strings.Join(args, ", "))
+	case "Connect":
+		return fmt.Sprintf("Connect(%q, %q)", r.from, r.to)
+	case "Input":
+		return fmt.Sprintf("Input(%q, %q)", r.port, r.to)
+	case "Output":
+		return fmt.Sprintf("Output(%q, %q)", r.from, r.port)
+	}
+	return r.action
 }
 
 // NewBuilder returns a new BJK Builder.
@@ -54,8 +112,10 @@ func (c *Client) NewBuilder() *Builder {
 		Groups:                 map[string]*Builder{},
 		InputsAlreadyConnected: map[string]string{},
 		CheckUnusedGroupInputs: true,
+		Constraints:            c.Constraints,
 
 		groupFullInputPortNames: map[string]bool{},
+		groupInstances:          map[string]*Builder{},
 	}
 }
 
@@ -68,16 +128,38 @@ func (c *Client) NewBuilder() *Builder {
 // When referring to inputs or outputs of a node, its full name is followed
 // by a dot then the name of the input or output port. For example,
 // "Helix.wire-1" or "Helix.wire-1.CoilPair.coils-1-2.start_angle".
-func (b *Builder) AddNode(name string, args ...string) *Builder {
+// Each arg is either a "name=value" string (e.g. "size=vector(1,1,1)")
+// or an Arg returned by Scalar, Vector, Enum, or Str.
+func (b *Builder) AddNode(name string, args ...any) *Builder {
+	nodeArgs, err := toArgs(args)
+	if err != nil {
+		b.errs = append(b.errs, fmt.Errorf("AddNode(%q): %w", name, err))
+		return b
+	}
+	return b.addNode(name, nodeArgs)
+}
+
+func (b *Builder) addNode(name string, args []Arg) *Builder {
 	if name == "" {
 		b.errs = append(b.errs, errors.New("AddNode: name cannot be empty"))
 		return b
 	}
 
 	if b.isGroup {
+		if !strings.Contains(name, ".") {
+			// auto-generate a label if the node doesn't have one, like instantiateNode.
+			var n int
+			for _, step := range b.groupRecorder {
+				if step.action == "AddNode" {
+					n++
+				}
+			}
+			name = fmt.Sprintf("%v.node-%v", name, n+1)
+		}
 		b.groupRecorder = append(b.groupRecorder, &recorder{
-			action: "AddNode",
-			args:   append([]string{name}, args...),
+			action:   "AddNode",
+			node:     name,
+			nodeArgs: args,
 		})
 		return b
 	}
@@ -87,54 +169,49 @@ func (b *Builder) AddNode(name string, args ...string) *Builder {
 	n, ok := b.c.Nodes[nodeType]
 	if !ok {
 		if g, ok := b.Groups[nodeType]; ok {
-			return b.instantiateGroup(name, g, args...)
+			return b.instantiateGroup(name, g, args)
 		}
-		b.errs = append(b.errs, fmt.Errorf("AddNode: unknown node type '%v'", nodeType))
+		b.errs = append(b.errs, &UnknownNodeTypeError{Op: "AddNode", Node: name, Type: nodeType, Suggestions: closeMatches(nodeType, b.nodeTypeNames())})
 		return b
 	}
 
-	return b.instantiateNode(nodeType, name, n, args...)
+	return b.instantiateNode(nodeType, name, n, args)
 }
 
-// injectGroupName takes a fullPortName (e.g. "Type.a.b.c.d") and a groupName (e.g. "MyGroup.instance")
-// and re-combines them such at the groupName is injected after the Type but before the labels of the fullPortName:
-// e.g. newFullPortName = "Type.MyGroup.instance.a.b.c.d", newNodeName = "Type.MyGroup.instance.a.b.c", portName = "d"
-func injectGroupName(fullPortName, groupName string) (newFullPortName, newNodeName, portName string) {
-	parts := strings.Split(fullPortName, ".")
-	baseName, portName := parts[0], strings.Join(parts[1:], ".")
-	newNodeName = fmt.Sprintf("%v.%v", baseName, groupName)
-	if portName == "" {
-		return newNodeName, newNodeName, ""
-	}
-	// To generate the final "newNodeName" and "portName" correctly, the portName has to be split if
-	// it already contains multiple parts.
-	newFullPortName = fmt.Sprintf("%v.%v", newNodeName, portName)
-	if len(parts) == 2 { // only one part to the portName - return as-is.
-		return newFullPortName, newNodeName, portName
+// injectGroupName takes a nodeName (e.g. "Type.a.b.c") and a groupName (e.g. "MyGroup.instance")
+// and re-combines them such that the groupName is injected after the Type but before the labels of the nodeName:
+// e.g. "Type.MyGroup.instance.a.b.c"
+func injectGroupName(nodeName, groupName string) string {
+	nodeType, labels, ok := strings.Cut(nodeName, ".")
+	if !ok {
+		return nodeType + "." + groupName
 	}
-	// Re-partition the pieces:
-	newNodeName = fmt.Sprintf("%v.%v.%v", baseName, groupName, strings.Join(parts[1:len(parts)-1], "."))
-	portName = parts[len(parts)-1]
-	return newFullPortName, newNodeName, portName
+	return nodeType + "." + groupName + "." + labels
 }
 
-func (b *Builder) instantiateGroup(groupName string, group *Builder, args ...string) *Builder {
+// injectGroupRef injects the groupName into the node name of a port (see injectGroupName).
+func injectGroupRef(ref PortRef, groupName string) PortRef {
+	return PortRef{Node: injectGroupName(ref.Node, groupName), Port: ref.Port}
+}
+
+func (b *Builder) instantiateGroup(groupName string, group *Builder, args []Arg) *Builder {
 	if b.c.debug {
 		log.Printf("Instantiating group '%v' with %v steps and args: %+v", groupName, len(group.groupRecorder), args)
 	}
 
-	staticArgs := map[string]string{}
+	b.groupInstances[groupName] = group
+
+	staticArgs := map[string]Arg{}
 	for _, arg := range args {
-		lhs, rhs, err := splitArg(arg)
-		if err != nil {
-			b.errs = append(b.errs, err)
-			return b
+		staticArgs[arg.Name] = arg
+		// Mark the group's input port as assigned so that an enclosing group's use of it counts.
+		if k := groupName + "." + arg.Name; b.InputsAlreadyConnected[k] == "" {
+			b.InputsAlreadyConnected[k] = strings.TrimSpace(arg.value())
 		}
-		staticArgs[lhs] = rhs
 	}
 
 	errFn := func(i int, step *recorder, msg string) error {
-		return fmt.Errorf("error: Group '%v' step #%v of %v: %v: %v('%v')", groupName, i+1, len(group.groupRecorder), msg, step.action, strings.Join(step.args, "', '"))
+		return fmt.Errorf("error: Group '%v' step #%v of %v: %v: %v", groupName, i+1, len(group.groupRecorder), msg, step)
 	}
 
 	// First pass - make sure we know all possible valid new node names (after instantiating) for this group
@@ -143,70 +220,80 @@ func (b *Builder) instantiateGroup(groupName string, group *Builder, args ...str
 		if step.action != "AddNode" {
 			continue
 		}
-		newFullNodeName, _, _ := injectGroupName(step.args[0], groupName) // not expecting a port name here.
-		validNewNodeNames[newFullNodeName] = true
+		validNewNodeNames[injectGroupName(step.node, groupName)] = true
 	}
 
 	// Next pass - make a map of the declared inputs that match the provided static args
 	// This map is keyed by the node name, whose value is one or more assignment statements.
-	namedArgs := map[string][]string{}
+	namedArgs := map[string][]Arg{}
 	for i, step := range group.groupRecorder {
 		if step.action != "Input" {
 			continue
 		}
 
-		newFullInputPortName, newToNodeName, portName := injectGroupName(step.args[1], groupName)
-		if portName == "" {
+		to := injectGroupRef(step.to, groupName)
+		if to.Port == "" {
 			b.errs = append(b.errs, errFn(i, step, "'to' node missing port"))
 			return b
 		}
 
-		b.groupFullInputPortNames[newFullInputPortName] = true
+		// Only the inputs of library nodes are checked; nested group instances forward theirs.
+		if _, ok := b.c.Nodes[nodeType(to.Node)]; ok {
+			b.groupFullInputPortNames[to.String()] = true
+		}
 
-		if staticArgs[step.args[0]] == "" {
+		arg, ok := staticArgs[step.port]
+		if !ok || (arg.kind == argString && arg.s == "") {
 			continue
 		}
 
-		if !validNewNodeNames[newToNodeName] {
-			b.errs = append(b.errs, errFn(i, step, fmt.Sprintf("'to' node %q not found, valid choices are: %+v", newToNodeName, maps.Keys(validNewNodeNames))))
+		if !validNewNodeNames[to.Node] {
+			b.errs = append(b.errs, errFn(i, step, fmt.Sprintf("'to' node %q not found, valid choices are: %+v", to.Node, maps.Keys(validNewNodeNames))))
 			return b
 		}
 
-		namedArgs[newToNodeName] = append(namedArgs[newToNodeName], fmt.Sprintf("%v=%v", portName, staticArgs[step.args[0]]))
+		arg.Name = to.Port
+		namedArgs[to.Node] = append(namedArgs[to.Node], arg)
 	}
 
 	// Final pass - whenever a named static argument is used, add it to the list of args to `AddNode`
 	for i, step := range group.groupRecorder {
 		if b.c.debug {
-			log.Printf("Group '%v' step #%v of %v: %v('%v') ...", groupName, i+1, len(group.groupRecorder), step.action, strings.Join(step.args, "', '"))
+			log.Printf("Group '%v' step #%v of %v: %v ...", groupName, i+1, len(group.groupRecorder), step)
 		}
 
 		switch step.action {
 		case "AddNode":
-			fullNodeName, _, _ := injectGroupName(step.args[0], groupName) // not expecting a port name here.
-			newArgs := append([]string{}, step.args[1:]...)
+			fullNodeName := injectGroupName(step.node, groupName)
+			newArgs := append([]Arg{}, step.nodeArgs...)
 			if v, ok := namedArgs[fullNodeName]; ok {
 				newArgs = append(newArgs, v...)
 			}
 			if b.c.debug {
 				log.Printf("calling: AddNode(%q, %+v)", fullNodeName, newArgs)
 			}
-			b = b.AddNode(fullNodeName, newArgs...)
+			if _, ok := b.c.Nodes[nodeType(fullNodeName)]; !ok {
+				if inner, ok := group.lookupGroup(nodeType(fullNodeName)); ok {
+					b = b.instantiateGroup(fullNodeName, inner, newArgs)
+					continue
+				}
+			}
+			b = b.addNode(fullNodeName, newArgs)
 		case "Connect":
-			fullFromPortName, _, portName := injectGroupName(step.args[0], groupName)
-			if portName == "" {
+			from := injectGroupRef(step.from, groupName)
+			if from.Port == "" {
 				b.errs = append(b.errs, errFn(i, step, "'from' node missing port"))
 				return b
 			}
-			fullToPortName, _, portName := injectGroupName(step.args[1], groupName)
-			if portName == "" {
+			to := injectGroupRef(step.to, groupName)
+			if to.Port == "" {
 				b.errs = append(b.errs, errFn(i, step, "'to' node missing port"))
 				return b
 			}
 			if b.c.debug {
-				log.Printf("calling: Connect(%q, %q)", fullFromPortName, fullToPortName)
+				log.Printf("calling: Connect(%q, %q)", from, to)
 			}
-			b = b.Connect(fullFromPortName, fullToPortName)
+			b = b.connect(from, to)
 		case "Input":
 		case "Output":
 		default:
@@ -214,6 +301,37 @@ func (b *Builder) instantiateGroup(groupName string, group *Builder, args ...str
 		}
 	}
 
+	// Optional inputs that were not assigned need not be used and take their defaults, if any.
+	inputs, _ := group.groupPorts()
+	for _, in := range inputs {
+		if arg, ok := staticArgs[in.Name]; !in.Optional || (ok && !(arg.kind == argString && arg.s == "")) {
+			continue
+		}
+		for _, port := range b.groupInputTargets(groupName, in.Name) {
+			delete(b.groupFullInputPortNames, port)
+			if in.Default == nil {
+				continue
+			}
+			ref, _ := toPortRef(port)
+			node, ok := b.Nodes[ref.Node]
+			if !ok {
+				continue
+			}
+			input, ok := node.GetInput(ref.Port)
+			if !ok {
+				continue
+			}
+			if err := in.Default.set(input); err != nil {
+				b.errs = append(b.errs, fmt.Errorf("error: Group '%v' input %q default: %w", groupName, in.Name, err))
+				return b
+			}
+			if err := b.checkInputProps(ref.Node, input); err != nil {
+				b.errs = append(b.errs, fmt.Errorf("error: Group '%v' input %q default: %w", groupName, in.Name, err))
+				return b
+			}
+		}
+	}
+
 	if b.c.debug {
 		log.Printf("Completed group '%v' with %v steps", groupName, len(group.groupRecorder))
 	}
@@ -221,7 +339,7 @@ func (b *Builder) instantiateGroup(groupName string, group *Builder, args ...str
 	return b
 }
 
-func (b *Builder) instantiateNode(nodeType, name string, n *ast.Node, args ...string) *Builder {
+func (b *Builder) instantiateNode(nodeType, name string, n *ast.Node, args []Arg) *Builder {
 	parts := strings.Split(name, ".")
 	if len(parts) == 1 {
 		// auto-generate a label if the node doesn't have one.
@@ -233,12 +351,31 @@ func (b *Builder) instantiateNode(nodeType, name string, n *ast.Node, args ...st
 		return b
 	}
 
-	// Make a deep copy of the node since this is a new instance and we don't want to share values.
-	inputs, err := b.setInputValues(name, n.Inputs, args...)
+	args, exprs := splitExprArgs(n, args)
+	node, err := b.newNode(nodeType, name, n, args)
 	if err != nil {
-		b.errs = append(b.errs, fmt.Errorf("setInputValues: %v", err))
+		b.errs = append(b.errs, err)
 		return b
 	}
+	node.Index = uint64(len(b.NodeOrder)) // 0-based indices
+	b.Nodes[name] = node
+	b.NodeOrder = append(b.NodeOrder, name)
+
+	for _, arg := range exprs {
+		b = b.bindExpr(name, arg)
+	}
+
+	return b
+}
+
+// newNode returns a new instance of the library node n with the given args.
+// Its Index is not set.
+func (b *Builder) newNode(nodeType, name string, n *ast.Node, args []Arg) (*ast.Node, error) {
+	// Make a deep copy of the node since this is a new instance and we don't want to share values.
+	inputs, err := b.setInputValues(name, n.Inputs, args)
+	if err != nil {
+		return nil, fmt.Errorf("setInputValues: %w", err)
+	}
 	outputs := make([]*ast.Output, 0, len(n.Outputs))
 	for _, out := range n.Outputs {
 		outputs = append(outputs, &ast.Output{Name: out.Name, DataType: out.DataType})
@@ -246,9 +383,8 @@ func (b *Builder) instantiateNode(nodeType, name string, n *ast.Node, args ...st
 
 	var nodePosition *ast.Vec2
 	for _, arg := range args {
-		const label = "node_position="
-		if strings.HasPrefix(arg, label) {
-			pos, ok := parseVec2(arg[len(label):])
+		if arg.Name == "node_position" {
+			pos, ok := parseVec2(strings.TrimSpace(arg.value()))
 			if !ok {
 				b.errs = append(b.errs, fmt.Errorf("unable to parse node '%v' arg: '%v'", name, arg))
 			}
@@ -256,24 +392,42 @@ func (b *Builder) instantiateNode(nodeType, name string, n *ast.Node, args ...st
 		}
 	}
 
-	b.Nodes[name] = &ast.Node{
+	return &ast.Node{
+		Name:        name,
 		OpName:      nodeType,
 		ReturnValue: n.ReturnValue, // OK not to make a deep copy of ReturnValue - it doesn't change.
 		Inputs:      inputs,
 		Outputs:     outputs,
 
 		Label: n.Label,
-		Index: uint64(len(b.NodeOrder)), // 0-based indices
 
 		NodePosition: nodePosition,
-	}
-	b.NodeOrder = append(b.NodeOrder, name)
+	}, nil
+}
 
-	return b
+func connectOp(from, to string) string {
+	return fmt.Sprintf("Connect(%q, %q)", from, to)
 }
 
 // Connect connects the `from` node.output_port to the `to` node.input_port.
-func (b *Builder) Connect(from, to string) *Builder {
+// Each port is either a string (e.g. "Helix.wire-1.out_mesh") or a PortRef
+// (e.g. helix.Out("out_mesh")) returned by a NodeRef.
+func (b *Builder) Connect(from, to any) *Builder {
+	fromRef, err := toPortRef(from)
+	if err != nil {
+		b.errs = append(b.errs, fmt.Errorf("Connect(%v, %v): 'from': %w", from, to, err))
+		return b
+	}
+	toRef, err := toPortRef(to)
+	if err != nil {
+		b.errs = append(b.errs, fmt.Errorf("Connect(%v, %v): 'to': %w", from, to, err))
+		return b
+	}
+	return b.connect(fromRef, toRef)
+}
+
+func (b *Builder) connect(fromRef, toRef PortRef) *Builder {
+	from, to := fromRef.String(), toRef.String()
 	if b.c.debug {
 		log.Printf("Connect(%q, %q)", from, to)
 	}
@@ -281,14 +435,14 @@ func (b *Builder) Connect(from, to string) *Builder {
 	if b.isGroup {
 		b.groupRecorder = append(b.groupRecorder, &recorder{
 			action: "Connect",
-			args:   []string{from, to},
+			from:   fromRef,
+			to:     toRef,
 		})
 		return b
 	}
 
-	fromParts := strings.Split(from, ".")
-	if len(fromParts) < 2 {
-		b.errs = append(b.errs, fmt.Errorf("Connect(%q, %q): unable to parse 'from' name: %[1]q want at least 2 parts, got %[3]v", from, to, len(fromParts)))
+	if fromRef.Node == "" || fromRef.Port == "" {
+		b.errs = append(b.errs, fmt.Errorf("Connect(%q, %q): unable to parse 'from' name: %[1]q want at least 2 parts", from, to))
 		return b
 	}
 
@@ -302,20 +456,20 @@ func (b *Builder) Connect(from, to string) *Builder {
 		return b
 	}
 
-	fromNodeName := strings.Join(fromParts[0:len(fromParts)-1], ".")
-	fromOutputName := fromParts[len(fromParts)-1]
+	fromNodeName := fromRef.Node
+	fromOutputName := fromRef.Port
 	fromNode, ok := b.Nodes[fromNodeName]
 	if !ok {
 		var connectionsMade int
-		if g, ok := b.Groups[fromParts[0]]; ok {
+		if g, ok := b.groupOf(fromNodeName); ok {
 			for _, step := range g.groupRecorder {
 				if b.c.debug {
-					log.Printf("Searching for group connection: fromNodeName=%q, fromOutputName=%q, step.action=%q, step.args=%+v", fromNodeName, fromOutputName, step.action, step.args)
+					log.Printf("Searching for group connection: fromNodeName=%q, fromOutputName=%q, step=%v", fromNodeName, fromOutputName, step)
 				}
-				if step.action == "Output" && step.args[1] == fromOutputName {
+				if step.action == "Output" && step.port == fromOutputName {
 					connectionsMade++
-					newFromPortName, _, portName := injectGroupName(step.args[0], fromNodeName)
-					if portName == "" {
+					newFrom := injectGroupRef(step.from, fromNodeName)
+					if newFrom.Port == "" {
 						msg := "Connect(%q, %q): 'from' node missing port"
 						if b.c.debug {
 							log.Fatalf("DEBUG MODE - %v PRIOR ERRORS! - ABORTING EARLY: "+msg, len(b.errs), from, to)
@@ -324,9 +478,9 @@ func (b *Builder) Connect(from, to string) *Builder {
 						return b
 					}
 					if b.c.debug {
-						log.Printf("Found group output connection: fromNodeName=%q, fromOutputName=%q, step.action=%q, step.args=%+v, newFromPortName=%q", fromNodeName, fromOutputName, step.action, step.args, newFromPortName)
+						log.Printf("Found group output connection: fromNodeName=%q, fromOutputName=%q, step=%v, newFrom=%q", fromNodeName, fromOutputName, step, newFrom)
 					}
-					b = b.Connect(newFromPortName, to)
+					b = b.connect(newFrom, toRef)
 				}
 			}
 		}
@@ -339,55 +493,58 @@ func (b *Builder) Connect(from, to string) *Builder {
 			log.Fatalf("DEBUG MODE - %v PRIOR ERRORS! - ABORTING EARLY: "+msg, len(b.errs), from, to, fromNodeName, maps.Keys(b.Nodes))
 		}
 
-		b.errs = append(b.errs, fmt.Errorf(msg, from, to, fromNodeName, maps.Keys(b.Nodes)))
+		b.errs = append(b.errs, &UnknownNodeError{Op: connectOp(from, to), Node: fromNodeName, Suggestions: closeMatches(fromNodeName, b.NodeOrder)})
 		return b
 	}
 
 	fromOutput, ok := fromNode.GetOutput(fromOutputName)
 	if !ok {
-		b.errs = append(b.errs, fmt.Errorf("Connect(%q, %q) unable to find 'from' node's output pin: %q; valid choices are: %+v", from, to, fromOutputName, fromNode.GetOutputs()))
+		b.errs = append(b.errs, &UnknownPortError{Op: connectOp(from, to), Node: fromNodeName, Port: fromOutputName, Output: true, Suggestions: closeMatches(fromOutputName, fromNode.GetOutputs())})
 		return b
 	}
 
-	toParts := strings.Split(to, ".")
-	if len(toParts) < 2 {
-		b.errs = append(b.errs, fmt.Errorf("Connect(%q, %q): unable to parse 'to' name: %[1]q want at least 2 parts, got %[3]v", from, to, len(toParts)))
+	if toRef.Node == "" || toRef.Port == "" {
+		b.errs = append(b.errs, fmt.Errorf("Connect(%q, %q): unable to parse 'to' name: %[2]q want at least 2 parts", from, to))
 		return b
 	}
 
-	toNodeName := strings.Join(toParts[0:len(toParts)-1], ".")
-	toInputName := toParts[len(toParts)-1]
+	toNodeName := toRef.Node
+	toInputName := toRef.Port
 	toNode, ok := b.Nodes[toNodeName]
 	if !ok {
 		if b.c.debug {
-			log.Printf("Checking groups %+v for '%v'", maps.Keys(b.Groups), toParts[0])
+			log.Printf("Checking groups %+v for '%v'", maps.Keys(b.Groups), nodeType(toNodeName))
 		}
 
 		var connectionsMade int
-		if g, ok := b.Groups[toParts[0]]; ok {
+		if g, ok := b.groupOf(toNodeName); ok {
 			for _, step := range g.groupRecorder {
-				if step.action == "Input" && step.args[0] == toInputName {
+				if step.action == "Input" && step.port == toInputName {
 					connectionsMade++
-					newToPortName, _, portName := injectGroupName(step.args[1], toNodeName)
-					if portName == "" {
+					newTo := injectGroupRef(step.to, toNodeName)
+					if newTo.Port == "" {
 						b.errs = append(b.errs, fmt.Errorf("Input(%q, %q): 'to' node missing port", from, to))
 						return b
 					}
-					b = b.Connect(from, newToPortName)
+					b = b.connect(fromRef, newTo)
 				}
 			}
 		}
 		if connectionsMade > 0 {
+			// Mark the group's input port as connected so that an enclosing group's use of it counts.
+			if _, ok := b.InputsAlreadyConnected[to]; !ok {
+				b.InputsAlreadyConnected[to] = from
+			}
 			return b
 		}
 
-		b.errs = append(b.errs, fmt.Errorf("Connect(%q, %q) unable to find 'to' node: %q; valid choices are: %+v", from, to, toNodeName, maps.Keys(b.Nodes)))
+		b.errs = append(b.errs, &UnknownNodeError{Op: connectOp(from, to), Node: toNodeName, Suggestions: closeMatches(toNodeName, b.NodeOrder)})
 		return b
 	}
 
 	toInput, ok := toNode.GetInput(toInputName)
 	if !ok {
-		b.errs = append(b.errs, fmt.Errorf("Connect(%q, %q) unable to find 'to' node's input pin: %q; valid choices are: %+v", from, to, toInputName, toNode.GetInputs()))
+		b.errs = append(b.errs, &UnknownPortError{Op: connectOp(from, to), Node: toNodeName, Port: toInputName, Suggestions: closeMatches(toInputName, toNode.GetInputs())})
 		return b
 	}
 
@@ -398,13 +555,13 @@ func (b *Builder) Connect(from, to string) *Builder {
 	}
 
 	if v, ok := b.InputsAlreadyConnected[to]; ok {
-		b.errs = append(b.errs, fmt.Errorf("error: Connect(%q, %q) - 'to' node '%[2]v' already connected OR statically assigned to %q", from, to, v))
+		b.errs = append(b.errs, &AlreadyConnectedError{Op: connectOp(from, to), Node: toNodeName, Port: toInputName, ConnectedTo: v, Suggestions: b.freeInputs(toNodeName, toInputName, fromOutput.DataType)})
 		return b
 	}
 	b.InputsAlreadyConnected[to] = from
 
 	if toInput.DataType != fromOutput.DataType {
-		b.errs = append(b.errs, fmt.Errorf("error: Connect(%q, %q) - 'from' node type '%v' not compatible with 'to' node type '%v'", from, to, fromOutput.DataType, toInput.DataType))
+		b.errs = append(b.errs, &TypeMismatchError{Op: connectOp(from, to), From: fromRef, To: toRef, FromType: fromOutput.DataType, ToType: toInput.DataType, Suggestions: b.freeInputs(toNodeName, toInputName, fromOutput.DataType)})
 	}
 
 	return b
@@ -420,24 +577,20 @@ func splitArg(arg string) (lhs, rhs string, err error) {
 	return lhs, rhs, nil
 }
 
-func (b *Builder) setInputValues(nodeName string, inputs []*ast.Input, args ...string) ([]*ast.Input, error) {
+func (b *Builder) setInputValues(nodeName string, inputs []*ast.Input, args []Arg) ([]*ast.Input, error) {
 	var result []*ast.Input
 
-	assignments := map[string]string{}
+	assignments := map[string]Arg{}
 	for _, arg := range args {
-		k, rhs, err := splitArg(arg)
-		if err != nil {
-			return nil, err
-		}
-
+		k := arg.Name
 		fullInputName := fmt.Sprintf("%v.%v", nodeName, k)
 		if v, ok := b.InputsAlreadyConnected[fullInputName]; ok {
-			return nil, fmt.Errorf("input '%v' already assigned to %q", fullInputName, v)
+			return nil, &AlreadyConnectedError{Op: "AddNode", Node: nodeName, Port: k, ConnectedTo: v}
 		}
-		v := strings.TrimSpace(rhs)
+		v := strings.TrimSpace(arg.value())
 		b.InputsAlreadyConnected[fullInputName] = v
 
-		assignments[k] = v
+		assignments[k] = arg
 		if b.c.debug {
 			log.Printf("setting input node '%v' = %v", fullInputName, v)
 		}
@@ -457,8 +610,15 @@ func (b *Builder) setInputValues(nodeName string, inputs []*ast.Input, args ...s
 		}
 
 		validInputNodes[input.Name] = true
-		if v, ok := assignments[input.Name]; ok {
-			if err := setInputProp(input, v); err != nil {
+		if arg, ok := assignments[input.Name]; ok {
+			if err := arg.set(input); err != nil {
+				var ce *ConstraintError
+				if errors.As(err, &ce) {
+					ce.Node = nodeName
+				}
+				return nil, err
+			}
+			if err := b.checkInputProps(nodeName, input); err != nil {
 				return nil, err
 			}
 			result = append(result, input)
@@ -476,7 +636,7 @@ func (b *Builder) setInputValues(nodeName string, inputs []*ast.Input, args ...s
 			continue
 		}
 		if !validInputNodes[k] {
-			b.errs = append(b.errs, fmt.Errorf("assignment to non-existing input port '%v' on node %q", k, nodeName))
+			b.errs = append(b.errs, &UnknownPortError{Op: "AddNode", Node: nodeName, Port: k, Suggestions: closeMatches(k, maps.Keys(validInputNodes))})
 		}
 	}
 
@@ -594,7 +754,7 @@ func setInputEnumValue(t lua.LString, input *ast.Input, valStr string) error {
 		return err
 	}
 	if !found {
-		return fmt.Errorf("setInputEnumValue: t=%v, input.Name='%v', props=%#v, values=%#v: enum '%v' not found", t, input.Name, input.Props, values, valStr)
+		return &ConstraintError{Input: input.Name, Constraint: "enum", Value: valStr, Limit: strings.Join(enumValues(input), ", ")}
 	}
 
 	input.Props["selected"] = lua.LNumber(index)
@@ -603,23 +763,16 @@ func setInputEnumValue(t lua.LString, input *ast.Input, valStr string) error {
 }
 
 func setInputScalarValue(t lua.LString, input *ast.Input, valStr string) error {
-	if _, ok := input.Props["default"]; !ok {
-		return fmt.Errorf("setInputScalarValue: t=%v, could not find 'default' for input %q: props=%#v", t, input.Name, input.Props)
-	}
-
 	x, err := strconv.ParseFloat(valStr, 64)
 	if err != nil {
 		return fmt.Errorf("setInputScalarValue: t=%v, input=%q, unable to parse value: '%v'", t, input.Name, valStr)
 	}
+	return setInputScalar(t, input, x)
+}
 
-	if minLVal, ok := input.Props["min"]; ok {
-		min, ok := minLVal.(lua.LNumber)
-		if !ok {
-			return fmt.Errorf("setInputScalarValue: t=%v, input=%q, min=%T, expected LNumber", t, input.Name, minLVal)
-		}
-		if x < float64(min) {
-			return fmt.Errorf("setInputScalarValue: t=%v, input=%q, attempt to set scalar (%v) < min (%v)", t, input.Name, x, min)
-		}
+func setInputScalar(t lua.LString, input *ast.Input, x float64) error {
+	if _, ok := input.Props["default"]; !ok {
+		return fmt.Errorf("setInputScalarValue: t=%v, could not find 'default' for input %q: props=%#v", t, input.Name, input.Props)
 	}
 
 	input.Props["default"] = lua.LNumber(x)
@@ -628,15 +781,6 @@ func setInputScalarValue(t lua.LString, input *ast.Input, valStr string) error {
 }
 
 func setInputVectorValue(t lua.LString, input *ast.Input, valStr string) error {
-	defLVal, ok := input.Props["default"]
-	if !ok {
-		return fmt.Errorf("setInputVectorValue: t=%v, could not find 'default' for input %q: props=%#v", t, input.Name, input.Props)
-	}
-	defVal, ok := defLVal.(*lua.LUserData)
-	if !ok {
-		return fmt.Errorf("setInputVectorValue: t=%v, defLVal=%T, want *ast.LUserData", t, defLVal)
-	}
-
 	const prefix = "vector("
 	if !strings.HasPrefix(valStr, prefix) || valStr[len(valStr)-1:] != ")" {
 		return fmt.Errorf("setInputVectorValue: t=%v, input=%q, want vector(x,y,z), got %v", t, input.Name, valStr)
@@ -662,7 +806,20 @@ func setInputVectorValue(t lua.LString, input *ast.Input, valStr string) error {
 		return fmt.Errorf("setInputVectorValue: t=%v, input=%q, unable to parse Z value: '%v'", t, input.Name, zStr)
 	}
 
-	defVal.Value = &Vec3{X: x, Y: y, Z: z}
+	return setInputVector(t, input, Vec3{X: x, Y: y, Z: z})
+}
+
+func setInputVector(t lua.LString, input *ast.Input, v Vec3) error {
+	defLVal, ok := input.Props["default"]
+	if !ok {
+		return fmt.Errorf("setInputVectorValue: t=%v, could not find 'default' for input %q: props=%#v", t, input.Name, input.Props)
+	}
+	defVal, ok := defLVal.(*lua.LUserData)
+	if !ok {
+		return fmt.Errorf("setInputVectorValue: t=%v, defLVal=%T, want *ast.LUserData", t, defLVal)
+	}
+
+	defVal.Value = &v
 
 	return nil
 }
@@ -697,10 +854,7 @@ func (b *Builder) Build() (*ast.BJK, error) {
 	}
 
 	if len(b.errs) > 0 {
-		if b.c.debug || len(b.errs) <= 5 {
-			return nil, fmt.Errorf("%v ERRORS FOUND:\n%w", len(b.errs), errors.Join(b.errs...))
-		}
-		return nil, fmt.Errorf("%v ERRORS FOUND - HERE ARE THE FIRST 5:\n%w", len(b.errs), errors.Join(b.errs[:5]...))
+		return nil, &BuildError{Errs: b.Errors(), showAll: b.c.debug}
 	}
 
 	bjk := ast.New()
@@ -709,17 +863,12 @@ func (b *Builder) Build() (*ast.BJK, error) {
 	}
 
 	g := bjk.Graph
-	ep := &b.ExternalParameters
+	// Copy the ParamValues so that building more than once (e.g. before and after Clone) does not repeat them.
+	ep := &ast.ExternalParameters{ParamValues: append([]*ast.ParamValue(nil), b.ExternalParameters.ParamValues...)}
 	addPV := func(pv *ast.ParamValue) { ep.ParamValues = append(ep.ParamValues, pv) }
 
-	g.UIData.NodePositions = make([]*ast.Vec2, len(b.NodeOrder))
 	g.UIData.NodeOrder = make([]uint64, len(b.NodeOrder))
-
-	lastXOffset, lastYOffset := float64(nodeXOffset), float64(-nodeYOffset)
 	for i, k := range b.NodeOrder {
-		lastXOffset += nodeXOffset
-		lastYOffset += nodeYOffset
-		g.UIData.NodePositions[i] = &ast.Vec2{X: lastXOffset, Y: lastYOffset}
 		g.UIData.NodeOrder[i] = uint64(i)
 
 		node, ok := b.Nodes[k]
@@ -728,38 +877,109 @@ func (b *Builder) Build() (*ast.BJK, error) {
 		}
 		g.Nodes = append(g.Nodes, node)
 
-		if node.NodePosition != nil {
-			g.UIData.NodePositions[i] = node.NodePosition
-			lastXOffset = node.NodePosition.X
-			lastYOffset = node.NodePosition.Y
-		}
-
 		// For each unconnected input, add an ExternalParameters value (except meshes).
-		for _, input := range node.Inputs {
-			if input.Kind.Connection != nil || input.DataType == "mesh" {
-				continue
-			}
+		pvs, err := externalParamValues(node)
+		if err != nil {
+			return nil, fmt.Errorf("Build: node '%v': %w", k, err)
+		}
+		for _, pv := range pvs {
+			addPV(pv)
+		}
+	}
 
-			ve, err := getValueEnum(input)
-			if err != nil {
-				return nil, fmt.Errorf("Build: node '%v': %w", k, err)
-			}
+	if ui := b.uiState; ui != nil {
+		g.UIData.Pan = ui.pan
+		g.UIData.Zoom = ui.zoom
+		g.UIData.NodeOrder = b.nodeIndices(ui.nodeOrder, true)
+		g.UIData.LockedGizmoNodes = b.nodeIndices(ui.lockedGizmoNodes, false)
+	}
 
-			addPV(&ast.ParamValue{
-				NodeIdx:   node.Index,
-				ParamName: input.Name,
-				ValueEnum: *ve,
-			})
-		}
+	positions, err := b.nodePositions(g)
+	if err != nil {
+		return nil, fmt.Errorf("Build: %w", err)
 	}
+	g.UIData.NodePositions = positions
 
 	dn := uint64(len(b.NodeOrder) - 1)
+	if b.DefaultNode != "" {
+		node, ok := b.Nodes[b.DefaultNode]
+		if !ok {
+			return nil, fmt.Errorf("Build: unknown default node '%v'", b.DefaultNode)
+		}
+		dn = node.Index
+	}
 	g.DefaultNode = &dn
 	g.ExternalParameters = ep
 
 	return bjk, nil
 }
 
+// nodePositions returns the position of each node in the graph according to b.Layout.
+// Nodes with an explicit 'node_position' are always placed there.
+func (b *Builder) nodePositions(g *ast.Graph) ([]*ast.Vec2, error) {
+	positions := make([]*ast.Vec2, len(g.Nodes))
+
+	switch b.Layout {
+	case LayoutDiagonal:
+		lastXOffset, lastYOffset := float64(nodeXOffset), float64(-nodeYOffset)
+		for i, node := range g.Nodes {
+			lastXOffset += nodeXOffset
+			lastYOffset += nodeYOffset
+			positions[i] = &ast.Vec2{X: lastXOffset, Y: lastYOffset}
+			if node.NodePosition != nil {
+				positions[i] = node.NodePosition
+				lastXOffset = node.NodePosition.X
+				lastYOffset = node.NodePosition.Y
+			}
+		}
+	case LayoutLayered:
+		// Start at the same place as the first node of the diagonal layout.
+		const xOffset = 2 * nodeXOffset
+		fixed := make([]*ast.Vec2, len(g.Nodes))
+		for i, node := range g.Nodes {
+			if p := node.NodePosition; p != nil {
+				fixed[i] = &ast.Vec2{X: p.X - xOffset, Y: p.Y}
+			}
+		}
+		layout, err := g.LayeredLayout(&ast.LayoutOptions{LayerSpacing: nodeXOffset, NodeSpacing: nodeYOffset, Fixed: fixed})
+		if err != nil {
+			return nil, err
+		}
+		for i, node := range g.Nodes {
+			positions[i] = &ast.Vec2{X: layout[i].X + xOffset, Y: layout[i].Y}
+			if node.NodePosition != nil {
+				positions[i] = node.NodePosition
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unknown layout mode: %v", b.Layout)
+	}
+
+	return positions, nil
+}
+
+// externalParamValues returns a ParamValue for each unconnected input of the node (except meshes).
+func externalParamValues(node *ast.Node) ([]*ast.ParamValue, error) {
+	var result []*ast.ParamValue
+	for _, input := range node.Inputs {
+		if input.Kind.Connection != nil || input.DataType == "mesh" {
+			continue
+		}
+
+		ve, err := getValueEnum(input)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, &ast.ParamValue{
+			NodeIdx:   node.Index,
+			ParamName: input.Name,
+			ValueEnum: *ve,
+		})
+	}
+	return result, nil
+}
+
 func getValueEnum(input *ast.Input) (*ast.ValueEnum, error) {
 	tAny, ok := input.Props["type"]
 	if !ok {
@@ -86,6 +86,14 @@ func TestBuild(t *testing.T) {
 		t.Fatal(err)
 	}
 
+	if got, want := design.Graph.Nodes[1].Name, "Helix.wire-1"; got != want {
+		t.Errorf("Nodes[1].Name = %q, want %q", got, want)
+	}
+	// Blackjack does not save node names, so clear them to match the manually-generated test data.
+	for _, n := range design.Graph.Nodes {
+		n.Name = ""
+	}
+
 	// Force the UIData to match the manually-generated test data.
 	ui := design.Graph.UIData
 	ui.NodePositions = []*ast.Vec2{
@@ -160,3 +168,39 @@ var wantParamsSortOrder = []string{
 	"8,flip",
 	"11,vec_b",
 }
+
+func TestBuild_Layout(t *testing.T) {
+	newBuilder := func(layout LayoutMode) *Builder {
+		b := fakeBuilder().
+			AddNode("Helix.a").
+			AddNode("Helix.b", "node_position=(1080,0)").
+			AddNode("MergeMeshes.m").
+			Connect("Helix.a.out_mesh", "MergeMeshes.m.mesh_a").
+			Connect("Helix.b.out_mesh", "MergeMeshes.m.mesh_b")
+		b.Layout = layout
+		return b
+	}
+
+	var zero LayoutMode
+	design, err := newBuilder(zero).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*ast.Vec2{{X: 720, Y: 0}, {X: 1080, Y: 0}, {X: 1440, Y: 60}}
+	if diff := cmp.Diff(want, design.Graph.UIData.NodePositions); diff != "" {
+		t.Errorf("default layout mismatch (-want +got):\n%v", diff)
+	}
+
+	design, err = newBuilder(LayoutLayered).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	positions := design.Graph.UIData.NodePositions
+	if diff := cmp.Diff(&ast.Vec2{X: 1080, Y: 0}, positions[1]); diff != "" {
+		t.Errorf("explicit node_position moved (-want +got):\n%v", diff)
+	}
+	// MergeMeshes.m is in the same column as Helix.b and must be moved below it.
+	if m, b := positions[2], positions[1]; m.X != b.X || m.Y < b.Y+nodeYOffset {
+		t.Errorf("MergeMeshes.m at %v overlaps Helix.b at %v", *m, *b)
+	}
+}
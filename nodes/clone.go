@@ -0,0 +1,134 @@
+package nodes
+
+import (
+	"github.com/gmlewis/go-bjk/ast"
+	"golang.org/x/exp/maps"
+)
+
+// Clone returns a deep copy of the builder (including its nodes, their
+// input values, groups, and connections) so that variants of a design can
+// be built from a common base without affecting each other.
+// The clone shares the builder's Client.
+func (b *Builder) Clone() *Builder {
+	return b.clone(map[*Builder]*Builder{})
+}
+
+// clone returns a deep copy of b, recording every cloned builder (b and its
+// groups) in cloned so that references to group definitions can be updated.
+func (b *Builder) clone(cloned map[*Builder]*Builder) *Builder {
+	nb := &Builder{
+		c:    b.c,
+		errs: append([]error(nil), b.errs...),
+
+		isGroup:                 b.isGroup,
+		parent:                  b.parent,
+		lastMergeMesh:           b.lastMergeMesh,
+		groupFullInputPortNames: maps.Clone(b.groupFullInputPortNames),
+
+		Nodes:     make(map[string]*ast.Node, len(b.Nodes)),
+		NodeOrder: append([]string(nil), b.NodeOrder...),
+		Groups:    make(map[string]*Builder, len(b.Groups)),
+
+		InputsAlreadyConnected: maps.Clone(b.InputsAlreadyConnected),
+
+		CheckUnusedGroupInputs: b.CheckUnusedGroupInputs,
+		Layout:                 b.Layout,
+		Constraints:            b.Constraints,
+		DefaultNode:            b.DefaultNode,
+	}
+
+	for _, r := range b.groupRecorder {
+		rc := *r // the input options are never modified once recorded
+		rc.nodeArgs = append([]Arg(nil), r.nodeArgs...)
+		nb.groupRecorder = append(nb.groupRecorder, &rc)
+	}
+	for name, node := range b.Nodes {
+		nb.Nodes[name] = cloneNode(node)
+	}
+	cloned[b] = nb
+	for name, g := range b.Groups {
+		gc := g.clone(cloned)
+		gc.parent = nb
+		nb.Groups[name] = gc
+	}
+	nb.params = maps.Clone(b.params)
+	if ui := b.uiState; ui != nil {
+		nb.uiState = &uiState{
+			pan:              ui.pan,
+			zoom:             ui.zoom,
+			nodeOrder:        append([]string(nil), ui.nodeOrder...),
+			lockedGizmoNodes: append([]string(nil), ui.lockedGizmoNodes...),
+		}
+	}
+	nb.groupInstances = make(map[string]*Builder, len(b.groupInstances))
+	for name, g := range b.groupInstances {
+		if gc, ok := cloned[g]; ok {
+			g = gc
+		}
+		nb.groupInstances[name] = g
+	}
+	for _, pv := range b.ExternalParameters.ParamValues {
+		nb.ExternalParameters.ParamValues = append(nb.ExternalParameters.ParamValues, &ast.ParamValue{
+			NodeIdx:   pv.NodeIdx,
+			ParamName: pv.ParamName,
+			ValueEnum: cloneValueEnum(pv.ValueEnum),
+		})
+	}
+
+	return nb
+}
+
+func cloneNode(n *ast.Node) *ast.Node {
+	result := &ast.Node{
+		Name:        n.Name,
+		OpName:      n.OpName,
+		ReturnValue: n.ReturnValue, // OK not to make a deep copy of ReturnValue - it doesn't change.
+		Label:       n.Label,
+		Index:       n.Index,
+	}
+	if n.NodePosition != nil {
+		pos := *n.NodePosition
+		result.NodePosition = &pos
+	}
+
+	for _, in := range n.Inputs {
+		input := &ast.Input{
+			Name:     in.Name,
+			DataType: in.DataType,
+			Props:    deepCopyProps(in.Props),
+		}
+		if ext := in.Kind.External; ext != nil {
+			input.Kind.External = &ast.External{Promoted: ext.Promoted}
+		}
+		if conn := in.Kind.Connection; conn != nil {
+			input.Kind.Connection = &ast.Connection{NodeIdx: conn.NodeIdx, ParamName: conn.ParamName}
+		}
+		result.Inputs = append(result.Inputs, input)
+	}
+	for _, out := range n.Outputs {
+		result.Outputs = append(result.Outputs, &ast.Output{Name: out.Name, DataType: out.DataType})
+	}
+
+	return result
+}
+
+func cloneValueEnum(ve ast.ValueEnum) ast.ValueEnum {
+	var result ast.ValueEnum
+	if ve.Scalar != nil {
+		v := *ve.Scalar
+		result.Scalar = &v
+	}
+	if ve.StrVal != nil {
+		v := *ve.StrVal
+		result.StrVal = &v
+	}
+	if ve.Selection != nil {
+		v := *ve.Selection
+		result.Selection = &v
+	}
+	if ve.Vector != nil {
+		v := *ve.Vector
+		result.Vector = &v
+	}
+	return result
+}
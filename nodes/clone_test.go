@@ -0,0 +1,59 @@
+package nodes
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	lua "github.com/yuin/gopher-lua"
+)
+
+func TestBuilder_Clone(t *testing.T) {
+	base := fakeBuilder().
+		NewGroup("Pair", func(b *Builder) *Builder {
+			return b.AddNode("Helix.one").
+				AddNode("Helix.two").
+				AddNode("MergeMeshes.pair").
+				Connect("Helix.one.out_mesh", "MergeMeshes.pair.mesh_a").
+				Connect("Helix.two.out_mesh", "MergeMeshes.pair.mesh_b").
+				Input("segments", "Helix.one.segments").
+				Output("MergeMeshes.pair.out_mesh", "out_mesh")
+		}).
+		AddNode("Helix.a", Vector("size", Vec3{X: 1, Y: 2, Z: 3})).
+		AddNode("Pair.p", Scalar("segments", 12))
+	want, err := base.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	variant := base.Clone().
+		SetInput("Helix.a", Vector("size", Vec3{X: 4, Y: 5, Z: 6})).
+		AddNode("Pair.q").
+		AddNode("MergeMeshes.all").
+		Connect("Helix.a.out_mesh", "MergeMeshes.all.mesh_a").
+		Connect("MergeMeshes.Pair.p.pair.out_mesh", "MergeMeshes.all.mesh_b")
+	variant.Groups["Pair"].AddNode("Helix.three")
+	if len(variant.errs) > 0 {
+		t.Fatalf("unexpected errors: %v", variant.errs)
+	}
+
+	if got, want := len(variant.NodeOrder), 8; got != want {
+		t.Errorf("variant has %v nodes, want %v", got, want)
+	}
+	if got, want := len(variant.Groups["Pair"].groupRecorder), len(base.Groups["Pair"].groupRecorder)+1; got != want {
+		t.Errorf("variant group has %v steps, want %v", got, want)
+	}
+
+	size := variant.Nodes["Helix.a"].Inputs[3].Props["default"].(*lua.LUserData).Value
+	if diff := cmp.Diff(&Vec3{X: 4, Y: 5, Z: 6}, size); diff != "" {
+		t.Errorf("variant size mismatch (-want +got):\n%v", diff)
+	}
+
+	// The base design is unaffected by the variant.
+	got, err := base.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want.String(), got.String()); diff != "" {
+		t.Errorf("base design changed (-want +got):\n%v", diff)
+	}
+}
@@ -0,0 +1,335 @@
+package nodes
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/gmlewis/go-bjk/ast"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// GenerateGo returns the source code of a Go package named pkgName that
+// provides a typed API for adding every node in c.Nodes to a Builder.
+// For each node, it generates an options struct (e.g. HelixOpts), a
+// constructor (e.g. Helix), and a handle with accessors for each of the
+// node's input and output ports (e.g. HelixNode). See cmd/bjk-gen-typed.
+func (c *Client) GenerateGo(pkgName string) ([]byte, error) {
+	return generateGo(pkgName, c.Nodes)
+}
+
+func generateGo(pkgName string, library map[string]*ast.Node) ([]byte, error) {
+	g := &codegen{used: map[string]bool{}}
+
+	opNames := make([]string, 0, len(library))
+	for opName := range library {
+		opNames = append(opNames, opName)
+	}
+	sort.Strings(opNames)
+
+	// Reserve the names of the node types first so they are never taken by enum values.
+	typeNames := map[string][3]string{}
+	for _, opName := range opNames {
+		op := goName(opName)
+		typeNames[opName] = [3]string{g.unique(op), g.unique(op + "Opts"), g.unique(op + "Node")}
+	}
+
+	for _, opName := range opNames {
+		g.genNode(library[opName], typeNames[opName])
+	}
+
+	body := g.buf.String()
+	g.buf.Reset()
+	g.p("// Code generated by bjk-gen-typed; DO NOT EDIT.")
+	g.p("")
+	g.p("package %v", pkgName)
+	g.p("")
+	g.p("import %q", "github.com/gmlewis/go-bjk/nodes")
+	g.buf.WriteString(body)
+
+	src, err := format.Source(g.buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("GenerateGo: %w\n%s", err, g.buf.Bytes())
+	}
+	return src, nil
+}
+
+type codegen struct {
+	buf  bytes.Buffer
+	used map[string]bool
+}
+
+func (g *codegen) p(fmtStr string, args ...any) {
+	fmt.Fprintf(&g.buf, fmtStr+"\n", args...)
+}
+
+// unique returns name, or name with a numeric suffix if it is already used.
+func (g *codegen) unique(name string) string {
+	result := name
+	for i := 2; g.used[result]; i++ {
+		result = fmt.Sprintf("%v%v", name, i)
+	}
+	g.used[result] = true
+	return result
+}
+
+// genField describes an options struct field for an external input.
+type genField struct {
+	input   *ast.Input
+	name    string
+	goType  string
+	argExpr string // expression converting the field to an AddNode arg
+	zero    string
+	doc     string
+	setter  string // name of the method that sets the field even to its zero value
+	force   string // name of the unexported field recording that the setter was called
+}
+
+// genNode generates the enums, options struct, constructor, and node handle
+// for a node using the given names for the constructor, options, and handle.
+func (g *codegen) genNode(n *ast.Node, names [3]string) {
+	op := goName(n.OpName)
+	funcName, optsName, nodeName := names[0], names[1], names[2]
+
+	var fields []*genField
+	var enums []string
+	fieldNames := map[string]bool{}
+	for _, input := range n.Inputs {
+		t, _ := input.Props["type"].(lua.LString)
+		f := &genField{input: input, name: goName(input.Name)}
+		for fieldNames[f.name] {
+			f.name += "_"
+		}
+
+		switch t {
+		case "scalar":
+			f.goType, f.zero = "float64", "0"
+			f.argExpr = fmt.Sprintf("nodes.Scalar(%q, opts.%v)", input.Name, f.name)
+		case "vec3":
+			f.goType, f.zero = "nodes.Vec3", "(nodes.Vec3{})"
+			f.argExpr = fmt.Sprintf("nodes.Vector(%q, opts.%v)", input.Name, f.name)
+		case "string", "lua_string", "selection":
+			f.goType, f.zero = "string", `""`
+			f.argExpr = fmt.Sprintf("nodes.Str(%q, opts.%v)", input.Name, f.name)
+		case "enum":
+			values := enumValues(input)
+			if len(values) == 0 {
+				continue
+			}
+			f.goType, f.zero = g.unique(op+f.name), `""`
+			f.argExpr = fmt.Sprintf("nodes.Enum(%q, string(opts.%v))", input.Name, f.name)
+			enums = append(enums, g.genEnum(op, f, values))
+		default: // meshes must be connected; other types cannot be set by the Builder.
+			continue
+		}
+		fieldNames[f.name] = true
+		f.doc = fieldDoc(input, t)
+		fields = append(fields, f)
+	}
+	for _, f := range fields {
+		f.setter, f.force = "Set"+f.name, "force"+f.name
+		for fieldNames[f.setter] {
+			f.setter += "_"
+		}
+	}
+
+	for _, enum := range enums {
+		g.buf.WriteString(enum)
+	}
+
+	g.genOpts(n, optsName, fields)
+	g.p("")
+	g.p("// %v is a %v node that has been added to a nodes.Builder.", nodeName, n.OpName)
+	g.p("type %v struct {", nodeName)
+	g.p("name string")
+	g.p("}")
+
+	g.p("")
+	g.p("// %v adds a %v node to the builder and returns it.", funcName, n.OpName)
+	g.p("// The node's full name is %q followed by a dot and the label or,", n.OpName)
+	g.p("// if the label is empty, the label generated by AddNode (e.g. %q).", n.OpName+".node-3")
+	g.p("func %v(b *nodes.Builder, label string, opts *%v) %v {", funcName, optsName, nodeName)
+	g.p("name := %q", n.OpName)
+	g.p("if label != \"\" {")
+	g.p("name += \".\" + label")
+	g.p("}")
+	g.p("ref := b.AddNodeRef(name, opts.args()...)")
+	g.p("return %v{name: ref.Name}", nodeName)
+	g.p("}")
+
+	g.p("")
+	g.p("// NodeName returns the full name of the node.")
+	g.p("func (n %v) NodeName() string { return n.name }", nodeName)
+
+	methods := map[string]bool{"NodeName": true}
+	for _, output := range n.Outputs {
+		name := goName(output.Name)
+		for methods[name] {
+			name = "Out" + name
+		}
+		methods[name] = true
+		g.p("")
+		g.p("// %v returns the full name of the %q output port.", name, output.Name)
+		g.p("func (n %v) %v() string { return n.name + %q }", nodeName, name, "."+output.Name)
+	}
+	for _, input := range n.Inputs {
+		name := "In" + goName(input.Name)
+		for methods[name] {
+			name += "_"
+		}
+		methods[name] = true
+		g.p("")
+		g.p("// %v returns the full name of the %q input port.", name, input.Name)
+		g.p("func (n %v) %v() string { return n.name + %q }", nodeName, name, "."+input.Name)
+	}
+}
+
+// genOpts generates the options struct for a node and its args method.
+func (g *codegen) genOpts(n *ast.Node, optsName string, fields []*genField) {
+	g.p("")
+	if len(fields) == 0 {
+		g.p("// %v is empty because a %v node has no external parameters.", optsName, n.OpName)
+		g.p("type %v struct{}", optsName)
+		g.p("")
+		g.p("// args returns the AddNode args for the options.")
+		g.p("func (opts *%v) args() []any { return nil }", optsName)
+		return
+	}
+
+	g.p("// %v holds the external parameters of a %v node.", optsName, n.OpName)
+	g.p("// Fields left at their zero values are not set, so the node uses its")
+	g.p("// default values. To set a field to its zero value, use its Set method")
+	g.p("// (e.g. %v).", fields[0].setter)
+	g.p("type %v struct {", optsName)
+	for _, f := range fields {
+		g.p("// %v", f.doc)
+		g.p("%v %v", f.name, f.goType)
+	}
+	g.p("")
+	g.p("// These record which fields were set by their Set methods.")
+	for _, f := range fields {
+		g.p("%v bool", f.force)
+	}
+	g.p("}")
+
+	for _, f := range fields {
+		g.p("")
+		g.p("// %v sets %v, even if v is its zero value, and returns opts", f.setter, f.name)
+		g.p("// (or new options if opts is nil).")
+		g.p("func (opts *%v) %v(v %v) *%v {", optsName, f.setter, f.goType, optsName)
+		g.p("if opts == nil {")
+		g.p("opts = &%v{}", optsName)
+		g.p("}")
+		g.p("opts.%v, opts.%v = v, true", f.name, f.force)
+		g.p("return opts")
+		g.p("}")
+	}
+
+	g.p("")
+	g.p("// args returns the AddNode args for the options.")
+	g.p("func (opts *%v) args() []any {", optsName)
+	g.p("if opts == nil {")
+	g.p("return nil")
+	g.p("}")
+	g.p("var args []any")
+	for _, f := range fields {
+		g.p("if opts.%v != %v || opts.%v {", f.name, f.zero, f.force)
+		g.p("args = append(args, %v)", f.argExpr)
+		g.p("}")
+	}
+	g.p("return args")
+	g.p("}")
+}
+
+// genEnum returns the declaration of an enum type and its values.
+func (g *codegen) genEnum(op string, f *genField, values []string) string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "\n// %v is the type of the %q input of the %v node.\n", f.goType, f.input.Name, op)
+	fmt.Fprintf(&buf, "type %v string\n\n", f.goType)
+	fmt.Fprintf(&buf, "// Values of %v.\n", f.goType)
+	buf.WriteString("const (\n")
+	for _, v := range values {
+		name := op + goName(v)
+		if g.used[name] {
+			name = op + f.name + goName(v)
+		}
+		fmt.Fprintf(&buf, "%v %v = %q\n", g.unique(name), f.goType, v)
+	}
+	buf.WriteString(")\n")
+	return buf.String()
+}
+
+// fieldDoc describes the input's type, default value, and limits.
+func fieldDoc(input *ast.Input, t lua.LString) string {
+	parts := []string{fmt.Sprintf("%v (%v)", input.Name, t)}
+	if t == "enum" {
+		values := enumValues(input)
+		selected, _ := input.Props["selected"].(lua.LNumber)
+		if i := int(selected); i >= 0 && i < len(values) {
+			parts = append(parts, fmt.Sprintf("default: %v", values[i]))
+		}
+		return strings.Join(parts, ", ")
+	}
+
+	switch v := input.Props["default"].(type) {
+	case lua.LNumber:
+		parts = append(parts, "default: "+formatFloat(float64(v)))
+	case lua.LString:
+		if v != "" {
+			parts = append(parts, fmt.Sprintf("default: %q", string(v)))
+		}
+	case *lua.LUserData:
+		if vec, ok := v.Value.(*Vec3); ok {
+			parts = append(parts, fmt.Sprintf("default: (%v, %v, %v)", formatFloat(vec.X), formatFloat(vec.Y), formatFloat(vec.Z)))
+		}
+	}
+	for _, k := range []string{"min", "max", "soft_min", "soft_max"} {
+		if v, ok := input.Props[k].(lua.LNumber); ok {
+			parts = append(parts, fmt.Sprintf("%v: %v", k, formatFloat(float64(v))))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func enumValues(input *ast.Input) []string {
+	values, ok := input.Props["values"].(*lua.LTable)
+	if !ok {
+		return nil
+	}
+	var result []string
+	values.ForEach(func(_, v lua.LValue) {
+		result = append(result, v.String())
+	})
+	return result
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// goName converts a name like "start_angle" or "Counter-Clockwise" to an
+// exported Go identifier like "StartAngle" or "CounterClockwise".
+func goName(s string) string {
+	var sb strings.Builder
+	upper := true
+	for _, r := range s {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		sb.WriteRune(r)
+	}
+	result := sb.String()
+	if result == "" || !unicode.IsLetter([]rune(result)[0]) {
+		result = "X" + result
+	}
+	return result
+}
@@ -0,0 +1,77 @@
+package nodes
+
+import (
+	goast "go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+	"testing"
+)
+
+func TestGenerateGo(t *testing.T) {
+	src, err := generateGo("typed", fakeLibrary())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "nodes_gen.go", src, 0)
+	if err != nil {
+		t.Fatalf("generated code does not parse: %v\n%s", err, src)
+	}
+
+	// Type-check the generated code against the nodes package.
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	if _, err := conf.Check("typed", fset, []*goast.File{f}, nil); err != nil {
+		t.Fatalf("generated code does not type-check: %v\n%s", err, src)
+	}
+
+	got := string(src)
+	for _, want := range []string{
+		"type HelixDirection string",
+		`HelixClockwise        HelixDirection = "Clockwise"`,
+		`HelixCounterClockwise HelixDirection = "Counter-Clockwise"`,
+		"// start_angle (scalar), default: 0\n\tStartAngle float64",
+		"// segments (scalar), default: 36, min: 3\n\tSegments float64",
+		"// direction (enum), default: Counter-Clockwise\n\tDirection HelixDirection",
+		"// size (vec3), default: (1, 1, 1)\n\tSize nodes.Vec3",
+		`args = append(args, nodes.Scalar("start_angle", opts.StartAngle))`,
+		`args = append(args, nodes.Enum("direction", string(opts.Direction)))`,
+		`args = append(args, nodes.Vector("size", opts.Size))`,
+		"func Helix(b *nodes.Builder, label string, opts *HelixOpts) HelixNode {",
+		"ref := b.AddNodeRef(name, opts.args()...)",
+		"func (opts *HelixOpts) SetStartAngle(v float64) *HelixOpts {",
+		"opts.StartAngle, opts.forceStartAngle = v, true",
+		"if opts.StartAngle != 0 || opts.forceStartAngle {",
+		`func (n HelixNode) OutMesh() string { return n.name + ".out_mesh" }`,
+		`func (n HelixNode) InStartAngle() string { return n.name + ".start_angle" }`,
+		"type MergeMeshesOpts struct{}",
+		`func (n MergeMeshesNode) InMeshA() string { return n.name + ".mesh_a" }`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("generated code missing %q:\n%v", want, got)
+		}
+	}
+}
+
+func TestGoName(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "start_angle", want: "StartAngle"},
+		{in: "Counter-Clockwise", want: "CounterClockwise"},
+		{in: "out_mesh", want: "OutMesh"},
+		{in: "x", want: "X"},
+		{in: "3d", want: "X3d"},
+		{in: "Front face", want: "FrontFace"},
+	}
+
+	for _, tt := range tests {
+		if got := goName(tt.in); got != tt.want {
+			t.Errorf("goName(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package nodes
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/gmlewis/go-bjk/ast"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// ConstraintPolicy selects how the Builder and Eval handle a value that
+// violates one of the constraints declared by an input of a Lua node.
+type ConstraintPolicy int
+
+const (
+	// PolicyHardLimits (the default) reports a value outside of the hard
+	// limits ('min', 'max', or the enum values) as an error, but only logs a
+	// warning when a value exceeds a soft limit ('soft_min', 'soft_max', or
+	// 'num_decimals'). Note that both the Builder and Eval check every hard
+	// limit; earlier versions only checked 'min', and only in the Builder.
+	PolicyHardLimits ConstraintPolicy = iota
+	// PolicyError reports every violated constraint as an error.
+	PolicyError
+	// PolicyClamp clamps a scalar to its hard limits ('min' and 'max') and,
+	// like PolicyHardLimits, only logs a warning when a value exceeds a soft limit.
+	// An unknown enum value is still an error.
+	PolicyClamp
+)
+
+// ConstraintError reports a value that violates a constraint of a node's input.
+type ConstraintError struct {
+	// Node identifies the node (e.g. "Helix.wire-1" or "3 (Helix)").
+	Node string
+	// Input is the name of the input.
+	Input string
+	// Constraint is one of "min", "max", "soft_min", "soft_max", "num_decimals", or "enum".
+	Constraint string
+	// Value is the offending value.
+	Value string
+	// Limit is the value of the constraint, or the valid choices of an enum.
+	Limit string
+}
+
+func (e *ConstraintError) Error() string {
+	var msg string
+	switch e.Constraint {
+	case "min", "soft_min":
+		msg = fmt.Sprintf("value %v is less than %v %v", e.Value, e.Constraint, e.Limit)
+	case "max", "soft_max":
+		msg = fmt.Sprintf("value %v is greater than %v %v", e.Value, e.Constraint, e.Limit)
+	case "num_decimals":
+		msg = fmt.Sprintf("value %v has more than %v decimals", e.Value, e.Limit)
+	case "enum":
+		msg = fmt.Sprintf("value %q is not one of: %v", e.Value, e.Limit)
+	default:
+		msg = fmt.Sprintf("value %v violates %v %v", e.Value, e.Constraint, e.Limit)
+	}
+	if e.Node == "" {
+		return fmt.Sprintf("input %q: %v", e.Input, msg)
+	}
+	return fmt.Sprintf("node %v input %q: %v", e.Node, e.Input, msg)
+}
+
+// IsSoft reports whether the violated constraint is a soft limit.
+func (e *ConstraintError) IsSoft() bool {
+	switch e.Constraint {
+	case "soft_min", "soft_max", "num_decimals":
+		return true
+	}
+	return false
+}
+
+// checkScalar checks x against the constraints in props and returns the
+// value to use, which differs from x only when the policy is PolicyClamp.
+// Soft-limit violations that are not errors are passed to warn.
+func checkScalar(policy ConstraintPolicy, warn func(*ConstraintError), node, input string, props map[string]lua.LValue, x float64) (float64, error) {
+	violation := func(constraint string, limit float64) *ConstraintError {
+		return &ConstraintError{Node: node, Input: input, Constraint: constraint, Value: formatFloat(x), Limit: formatFloat(limit)}
+	}
+
+	limits := []struct {
+		name  string
+		below bool // the value must not be below the limit
+	}{
+		{name: "min", below: true},
+		{name: "max"},
+		{name: "soft_min", below: true},
+		{name: "soft_max"},
+	}
+	for _, limit := range limits {
+		lv, ok := props[limit.name].(lua.LNumber)
+		if !ok {
+			continue
+		}
+		v := float64(lv)
+		if (limit.below && x >= v) || (!limit.below && x <= v) {
+			continue
+		}
+		err := violation(limit.name, v)
+		switch {
+		case policy != PolicyError && err.IsSoft():
+			warn(err)
+		case policy == PolicyClamp:
+			x = v
+		default:
+			return x, err
+		}
+	}
+
+	if lv, ok := props["num_decimals"].(lua.LNumber); ok && lv >= 0 {
+		scale := math.Pow(10, float64(lv))
+		rounded := math.Round(x*scale) / scale
+		if math.Abs(rounded-x) > 1e-9*math.Max(1, math.Abs(x)) {
+			err := violation("num_decimals", float64(lv))
+			if policy == PolicyError {
+				return x, err
+			}
+			warn(err)
+		}
+	}
+
+	return x, nil
+}
+
+// checkEnum checks that value is one of the values of the enum input.
+func checkEnum(node string, input *ast.Input, value string) error {
+	values := enumValues(input)
+	if slices.Contains(values, value) {
+		return nil
+	}
+	return &ConstraintError{Node: node, Input: input.Name, Constraint: "enum", Value: value, Limit: strings.Join(values, ", ")}
+}
+
+// warnConstraint logs a soft-limit violation, but only the first time that
+// it is seen for each node input so that repeated Evals don't flood the log.
+func (c *Client) warnConstraint(err *ConstraintError) {
+	k := fmt.Sprintf("%v.%v:%v", err.Node, err.Input, err.Constraint)
+	c.warnedMu.Lock()
+	defer c.warnedMu.Unlock()
+	if c.warned[k] {
+		return
+	}
+	if c.warned == nil {
+		c.warned = map[string]bool{}
+	}
+	c.warned[k] = true
+	log.Printf("WARNING: %v", err)
+}
+
+// checkInputProps checks the value of a node's input (stored in its props)
+// against the input's constraints, clamping it if the policy allows.
+func (b *Builder) checkInputProps(node string, input *ast.Input) error {
+	t, _ := input.Props["type"].(lua.LString)
+	if t != "scalar" {
+		return nil
+	}
+	x, ok := input.Props["default"].(lua.LNumber)
+	if !ok {
+		return nil
+	}
+	v, err := checkScalar(b.Constraints, b.c.warnConstraint, node, input.Name, input.Props, float64(x))
+	if err != nil {
+		return err
+	}
+	input.Props["default"] = lua.LNumber(v)
+	return nil
+}
+
+// checkEvalInput checks an external parameter or default value about to be
+// passed to the input of a node during Eval against the constraints declared
+// by the node library, returning the value to use. Values computed by
+// upstream nodes are not checked.
+func (c *Client) checkEvalInput(nodeIdx int, node *ast.Node, inputName string, lv lua.LValue) (lua.LValue, error) {
+	libNode, ok := c.Nodes[node.OpName]
+	if !ok {
+		return lv, nil
+	}
+	var input *ast.Input
+	for _, in := range libNode.Inputs {
+		if in.Name == inputName {
+			input = in
+			break
+		}
+	}
+	if input == nil {
+		return lv, nil
+	}
+
+	name := node.Name
+	if name == "" {
+		name = fmt.Sprintf("%v (%v)", nodeIdx, node.OpName)
+	}
+
+	t, _ := input.Props["type"].(lua.LString)
+	switch v := lv.(type) {
+	case lua.LNumber:
+		if t != "scalar" {
+			return lv, nil
+		}
+		x, err := checkScalar(c.Constraints, c.warnConstraint, name, inputName, input.Props, float64(v))
+		return lua.LNumber(x), err
+	case lua.LString:
+		if t != "enum" {
+			return lv, nil
+		}
+		return lv, checkEnum(name, input, string(v))
+	}
+	return lv, nil
+}
@@ -0,0 +1,132 @@
+package nodes
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gmlewis/go-bjk/ast"
+	lua "github.com/yuin/gopher-lua"
+)
+
+func TestCheckScalar(t *testing.T) {
+	props := map[string]lua.LValue{
+		"min":          lua.LNumber(0),
+		"max":          lua.LNumber(100),
+		"soft_max":     lua.LNumber(10),
+		"num_decimals": lua.LNumber(1),
+	}
+
+	tests := []struct {
+		name    string
+		policy  ConstraintPolicy
+		x       float64
+		want    float64
+		wantErr string // the violated constraint, if any
+	}{
+		{name: "in range", policy: PolicyError, x: 5.5, want: 5.5},
+		{name: "below min", policy: PolicyHardLimits, x: -1, wantErr: "min"},
+		{name: "above max", policy: PolicyHardLimits, x: 101, wantErr: "max"},
+		{name: "above soft_max warns", policy: PolicyHardLimits, x: 20, want: 20},
+		{name: "above soft_max errors", policy: PolicyError, x: 20, wantErr: "soft_max"},
+		{name: "too many decimals warns", policy: PolicyHardLimits, x: 1.25, want: 1.25},
+		{name: "too many decimals errors", policy: PolicyError, x: 1.25, wantErr: "num_decimals"},
+		{name: "clamp below min", policy: PolicyClamp, x: -1, want: 0},
+		{name: "clamp above max", policy: PolicyClamp, x: 101, want: 100},
+		{name: "clamp keeps above soft_max", policy: PolicyClamp, x: 20, want: 20},
+		{name: "clamp keeps decimals", policy: PolicyClamp, x: 1.26, want: 1.26},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := checkScalar(tt.policy, func(*ConstraintError) {}, "Helix.h", "turns", props, tt.x)
+			if tt.wantErr != "" {
+				var ce *ConstraintError
+				if !errors.As(err, &ce) || ce.Constraint != tt.wantErr {
+					t.Fatalf("checkScalar err = %v, want %v violation", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("checkScalar = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuilder_Constraints(t *testing.T) {
+	c := &Client{Nodes: fakeLibrary()}
+
+	b := c.NewBuilder()
+	b.AddNode("Helix.h", Scalar("segments", 2))
+	if len(b.errs) != 1 {
+		t.Fatalf("got %v errors, want 1: %v", len(b.errs), b.errs)
+	}
+	var ce *ConstraintError
+	if !errors.As(b.errs[0], &ce) {
+		t.Fatalf("error %v is not a *ConstraintError", b.errs[0])
+	}
+	if want := `node Helix.h input "segments": value 2 is less than min 3`; ce.Error() != want {
+		t.Errorf("error = %q, want %q", ce.Error(), want)
+	}
+
+	b = c.NewBuilder()
+	b.AddNode("Helix.h", "direction=Up")
+	if len(b.errs) != 1 || !errors.As(b.errs[0], &ce) || ce.Constraint != "enum" || ce.Node != "Helix.h" {
+		t.Fatalf("errs = %v, want an enum *ConstraintError", b.errs)
+	}
+
+	c.Constraints = PolicyClamp
+	b = c.NewBuilder()
+	b.AddNode("Helix.h", Scalar("segments", 2))
+	if len(b.errs) > 0 {
+		t.Fatalf("unexpected errors: %v", b.errs)
+	}
+	if got := b.Nodes["Helix.h"].Inputs[1].Props["default"]; got != lua.LNumber(3) {
+		t.Errorf("clamped segments = %v, want 3", got)
+	}
+}
+
+func TestClient_CheckEvalInput(t *testing.T) {
+	c := &Client{Nodes: fakeLibrary()}
+	node := &ast.Node{OpName: "Helix"}
+
+	if _, err := c.checkEvalInput(2, node, "segments", lua.LNumber(1)); err == nil || err.Error() != `node 2 (Helix) input "segments": value 1 is less than min 3` {
+		t.Errorf("segments err = %v", err)
+	}
+	if _, err := c.checkEvalInput(2, node, "direction", lua.LString("Sideways")); err == nil || err.Error() != `node 2 (Helix) input "direction": value "Sideways" is not one of: Clockwise, Counter-Clockwise` {
+		t.Errorf("direction err = %v", err)
+	}
+	if got, err := c.checkEvalInput(2, node, "direction", lua.LString("Clockwise")); err != nil || got != lua.LString("Clockwise") {
+		t.Errorf("direction = (%v, %v), want Clockwise", got, err)
+	}
+
+	c.Constraints = PolicyClamp
+	if got, err := c.checkEvalInput(2, node, "segments", lua.LNumber(1)); err != nil || got != lua.LNumber(3) {
+		t.Errorf("clamped segments = (%v, %v), want 3", got, err)
+	}
+}
+
+func TestClient_WarnConstraintOnce(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	c := &Client{Nodes: fakeLibrary()}
+	c.Nodes["Helix"].Inputs[0].Props["num_decimals"] = lua.LNumber(1)
+	node := &ast.Node{OpName: "Helix"}
+	for i := 0; i < 3; i++ {
+		if _, err := c.checkEvalInput(2, node, "start_angle", lua.LNumber(1.25)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := strings.Count(buf.String(), "WARNING"); got != 1 {
+		t.Errorf("got %v warnings, want 1:\n%v", got, buf.String())
+	}
+}
@@ -0,0 +1,444 @@
+package nodes
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gmlewis/go-bjk/ast"
+	"golang.org/x/exp/maps"
+)
+
+// Disconnect removes the connection to the `to` node.input_port, which is
+// either a string (e.g. "MergeMeshes.m.mesh_a") or a PortRef. The input
+// then reverts to its default value and can be connected or assigned again.
+func (b *Builder) Disconnect(to any) *Builder {
+	toRef, err := toPortRef(to)
+	if err != nil {
+		b.errs = append(b.errs, fmt.Errorf("Disconnect(%v): %w", to, err))
+		return b
+	}
+	node, input, err := b.editInput("Disconnect", toRef)
+	if err != nil {
+		b.errs = append(b.errs, err)
+		return b
+	}
+	if input.Kind.Connection == nil {
+		b.errs = append(b.errs, fmt.Errorf("Disconnect(%q): input is not connected", toRef))
+		return b
+	}
+
+	return b.resetInput(node, input)
+}
+
+// RemoveNode removes the named node (or every node of the named group
+// instance) from the design. Inputs that were connected to the removed
+// nodes revert to their default values, and the indices of the remaining
+// nodes are compacted.
+func (b *Builder) RemoveNode(name string) *Builder {
+	if b.isGroup {
+		b.errs = append(b.errs, fmt.Errorf("RemoveNode(%q) cannot be used within a group", name))
+		return b
+	}
+
+	remove, instances := map[string]bool{}, map[string]bool{}
+	if _, ok := b.Nodes[name]; ok {
+		remove[name] = true
+	} else if _, ok := b.groupInstances[name]; ok {
+		b.groupInstanceNodes(name, remove, instances)
+	}
+	if len(remove) == 0 {
+		b.errs = append(b.errs, &UnknownNodeError{Op: fmt.Sprintf("RemoveNode(%q)", name), Node: name, Suggestions: closeMatches(name, b.NodeOrder)})
+		return b
+	}
+	// The nodes compiled from expressions bound to the removed nodes' inputs go with them.
+	for nodeName := range maps.Clone(remove) {
+		for _, input := range b.Nodes[nodeName].Inputs {
+			for _, exprNode := range b.exprNodes(PortRef{Node: nodeName, Port: input.Name}) {
+				remove[exprNode] = true
+			}
+		}
+	}
+
+	removedIdx := map[uint64]bool{}
+	for nodeName := range remove {
+		removedIdx[b.Nodes[nodeName].Index] = true
+		b.forgetInputs(nodeName)
+	}
+	for k := range b.groupFullInputPortNames {
+		if remove[portNode(k)] {
+			delete(b.groupFullInputPortNames, k)
+		}
+	}
+	for k := range instances {
+		delete(b.groupInstances, k)
+	}
+
+	for k, port := range b.params {
+		if remove[port.Node] {
+			delete(b.params, k)
+		}
+	}
+
+	// Disconnect everything that depended on the removed nodes.
+	var reset []PortRef
+	for _, nodeName := range b.NodeOrder {
+		if remove[nodeName] {
+			continue
+		}
+		node := b.Nodes[nodeName]
+		for _, input := range node.Inputs {
+			if conn := input.Kind.Connection; conn != nil && removedIdx[conn.NodeIdx] {
+				b.clearInput(node, input)
+				reset = append(reset, PortRef{Node: nodeName, Port: input.Name})
+			}
+		}
+	}
+
+	// Compact the indices.
+	newIdx := map[uint64]uint64{}
+	var nodeOrder []string
+	for _, nodeName := range b.NodeOrder {
+		if remove[nodeName] {
+			delete(b.Nodes, nodeName)
+			continue
+		}
+		node := b.Nodes[nodeName]
+		newIdx[node.Index] = uint64(len(nodeOrder))
+		node.Index = uint64(len(nodeOrder))
+		nodeOrder = append(nodeOrder, nodeName)
+	}
+	b.NodeOrder = nodeOrder
+	for _, node := range b.Nodes {
+		for _, input := range node.Inputs {
+			if conn := input.Kind.Connection; conn != nil {
+				conn.NodeIdx = newIdx[conn.NodeIdx]
+			}
+		}
+	}
+
+	if remove[b.DefaultNode] {
+		b.DefaultNode = ""
+	}
+	if remove[portNode(b.lastMergeMesh)] {
+		b.lastMergeMesh = ""
+	}
+
+	for _, ref := range reset {
+		b = b.removeExprNodes(ref)
+	}
+	return b
+}
+
+// ReplaceNode replaces the named node with a new node of type newType
+// (keeping the node's position in the design and its labels, so that
+// "Helix.wire-1" replaced by a "Cube" becomes "Cube.wire-1").
+// Connections to and from the old node are kept when the new node has
+// a port of the same name and data type, as are the assigned values of
+// such inputs. The args (like those of AddNode) are applied last.
+// The nodes of expressions bound to inputs that are not kept are removed.
+func (b *Builder) ReplaceNode(name, newType string, args ...any) *Builder {
+	if b.isGroup {
+		b.errs = append(b.errs, fmt.Errorf("ReplaceNode(%q) cannot be used within a group", name))
+		return b
+	}
+	newArgs, err := toArgs(args)
+	if err != nil {
+		b.errs = append(b.errs, fmt.Errorf("ReplaceNode(%q): %w", name, err))
+		return b
+	}
+
+	old, ok := b.Nodes[name]
+	if !ok {
+		b.errs = append(b.errs, &UnknownNodeError{Op: fmt.Sprintf("ReplaceNode(%q)", name), Node: name, Suggestions: closeMatches(name, b.NodeOrder)})
+		return b
+	}
+	lib, ok := b.c.Nodes[newType]
+	if !ok {
+		b.errs = append(b.errs, &UnknownNodeTypeError{Op: fmt.Sprintf("ReplaceNode(%q)", name), Node: name, Type: newType, Suggestions: closeMatches(newType, maps.Keys(b.c.Nodes))})
+		return b
+	}
+	newName := newType + strings.TrimPrefix(name, old.OpName)
+	if _, ok := b.Nodes[newName]; ok && newName != name {
+		b.errs = append(b.errs, fmt.Errorf("ReplaceNode(%q): node '%v' already exists", name, newName))
+		return b
+	}
+
+	// Remember what was assigned to or connected to the old node's inputs.
+	oldAssigned := map[string]string{}
+	for _, input := range old.Inputs {
+		if v, ok := b.InputsAlreadyConnected[name+"."+input.Name]; ok {
+			oldAssigned[input.Name] = v
+		}
+	}
+	b.forgetInputs(name)
+
+	node, err := b.newNode(newType, newName, lib, newArgs)
+	if err != nil {
+		b.errs = append(b.errs, fmt.Errorf("ReplaceNode(%q): %w", name, err))
+		return b
+	}
+	node.Index = old.Index
+	if node.NodePosition == nil {
+		node.NodePosition = old.NodePosition
+	}
+
+	for _, oldInput := range old.Inputs {
+		v, ok := oldAssigned[oldInput.Name]
+		if !ok {
+			continue
+		}
+		full := newName + "." + oldInput.Name
+		input, ok := node.GetInput(oldInput.Name)
+		if _, assigned := b.InputsAlreadyConnected[full]; !ok || assigned || input.DataType != oldInput.DataType {
+			continue
+		}
+		if oldInput.Kind.Connection != nil {
+			conn := *oldInput.Kind.Connection
+			input.Kind.External = nil
+			input.Kind.Connection = &conn
+			b.InputsAlreadyConnected[full] = v
+			continue
+		}
+		if ve, err := getValueEnum(oldInput); err == nil && setInputValueEnum(input, ve) == nil {
+			b.InputsAlreadyConnected[full] = v
+		}
+	}
+
+	// Keep connections from the old node's outputs that the new node still provides.
+	var reset []PortRef
+	for _, nodeName := range b.NodeOrder {
+		other := b.Nodes[nodeName]
+		for _, input := range other.Inputs {
+			conn := input.Kind.Connection
+			if conn == nil || conn.NodeIdx != old.Index || nodeName == name {
+				continue
+			}
+			oldOutput, _ := old.GetOutput(conn.ParamName)
+			output, ok := node.GetOutput(conn.ParamName)
+			if !ok || oldOutput == nil || output.DataType != oldOutput.DataType {
+				b.clearInput(other, input)
+				reset = append(reset, PortRef{Node: nodeName, Port: input.Name})
+				continue
+			}
+			b.InputsAlreadyConnected[nodeName+"."+input.Name] = newName + "." + output.Name
+		}
+	}
+
+	b.Nodes[name] = node
+	if newName != name {
+		b.renameNode(name, newName)
+	}
+
+	for _, oldInput := range old.Inputs {
+		if oldInput.Kind.Connection == nil {
+			continue
+		}
+		oldRef := PortRef{Node: name, Port: oldInput.Name}
+		if input, ok := node.GetInput(oldInput.Name); !ok || input.Kind.Connection == nil {
+			reset = append(reset, oldRef)
+			continue
+		}
+		if newName == name {
+			continue
+		}
+		// Relabel the nodes of an expression whose connection was kept.
+		for _, exprNode := range b.exprNodes(oldRef) {
+			t := nodeType(exprNode)
+			b.renameNode(exprNode, t+"."+newName+strings.TrimPrefix(exprNode, t+"."+name))
+		}
+	}
+
+	// Forget the group input ports and parameters that the new node lacks.
+	for k := range b.groupFullInputPortNames {
+		if ref, _ := toPortRef(k); ref.Node == newName {
+			if _, ok := node.GetInput(ref.Port); !ok {
+				delete(b.groupFullInputPortNames, k)
+			}
+		}
+	}
+	for k, port := range b.params {
+		if _, ok := node.GetOutput(port.Port); port.Node == newName && !ok {
+			delete(b.params, k)
+		}
+	}
+
+	for _, ref := range reset {
+		b = b.removeExprNodes(ref)
+	}
+	return b
+}
+
+// renameNode renames a node and updates every reference to it.
+func (b *Builder) renameNode(from, to string) {
+	node := b.Nodes[from]
+	delete(b.Nodes, from)
+	b.Nodes[to] = node
+	b.NodeOrder[node.Index] = to
+	node.Name = to
+
+	rename := func(s string) string {
+		if s == from || portNode(s) == from {
+			return to + strings.TrimPrefix(s, from)
+		}
+		return s
+	}
+	for k, v := range b.InputsAlreadyConnected {
+		if nk := rename(k); nk != k {
+			delete(b.InputsAlreadyConnected, k)
+			k = nk
+		}
+		b.InputsAlreadyConnected[k] = rename(v)
+	}
+	for k := range b.groupFullInputPortNames {
+		if nk := rename(k); nk != k {
+			delete(b.groupFullInputPortNames, k)
+			b.groupFullInputPortNames[nk] = true
+		}
+	}
+	for k, port := range b.params {
+		if port.Node == from {
+			b.params[k] = PortRef{Node: to, Port: port.Port}
+		}
+	}
+	b.DefaultNode = rename(b.DefaultNode)
+	b.lastMergeMesh = rename(b.lastMergeMesh)
+	if ui := b.uiState; ui != nil {
+		for i, name := range ui.nodeOrder {
+			ui.nodeOrder[i] = rename(name)
+		}
+		for i, name := range ui.lockedGizmoNodes {
+			ui.lockedGizmoNodes[i] = rename(name)
+		}
+	}
+}
+
+// SetInput overrides the values of the named node's inputs, even if they
+// were previously assigned. Each arg is either a "name=value" string or an
+// Arg (e.g. Scalar("segments", 36)), like those passed to AddNode.
+// An input that is connected is disconnected first.
+func (b *Builder) SetInput(name string, args ...any) *Builder {
+	setArgs, err := toArgs(args)
+	if err != nil {
+		b.errs = append(b.errs, fmt.Errorf("SetInput(%q): %w", name, err))
+		return b
+	}
+
+	for _, arg := range setArgs {
+		ref := PortRef{Node: name, Port: arg.Name}
+		if arg.Name == "node_position" {
+			node, ok := b.Nodes[name]
+			if !ok || b.isGroup {
+				b.errs = append(b.errs, &UnknownNodeError{Op: fmt.Sprintf("SetInput(%q)", name), Node: name, Suggestions: closeMatches(name, b.NodeOrder)})
+				return b
+			}
+			pos, ok := parseVec2(strings.TrimSpace(arg.value()))
+			if !ok {
+				b.errs = append(b.errs, fmt.Errorf("SetInput(%q): unable to parse arg: '%v'", name, arg))
+				return b
+			}
+			node.NodePosition = pos
+			continue
+		}
+
+		node, input, err := b.editInput("SetInput", ref)
+		if err != nil {
+			b.errs = append(b.errs, err)
+			return b
+		}
+		if input.Kind.Connection != nil {
+			b = b.resetInput(node, input)
+		}
+		if _, exprs := splitExprArgs(node, []Arg{arg}); len(exprs) > 0 {
+			delete(b.InputsAlreadyConnected, ref.String())
+			b = b.bindExpr(name, arg)
+			continue
+		}
+		if err := arg.set(input); err != nil {
+			b.errs = append(b.errs, fmt.Errorf("SetInput(%q): %w", ref, err))
+			return b
+		}
+		if err := b.checkInputProps(name, input); err != nil {
+			b.errs = append(b.errs, fmt.Errorf("SetInput(%q): %w", ref, err))
+			return b
+		}
+		b.InputsAlreadyConnected[ref.String()] = strings.TrimSpace(arg.value())
+	}
+
+	return b
+}
+
+// editInput returns the node and input of a port that is about to be edited.
+func (b *Builder) editInput(op string, ref PortRef) (*ast.Node, *ast.Input, error) {
+	if b.isGroup {
+		return nil, nil, fmt.Errorf("%v(%q) cannot be used within a group", op, ref)
+	}
+	node, ok := b.Nodes[ref.Node]
+	if !ok {
+		return nil, nil, &UnknownNodeError{Op: fmt.Sprintf("%v(%q)", op, ref), Node: ref.Node, Suggestions: closeMatches(ref.Node, b.NodeOrder)}
+	}
+	input, ok := node.GetInput(ref.Port)
+	if !ok {
+		return nil, nil, &UnknownPortError{Op: fmt.Sprintf("%v(%q)", op, ref), Node: ref.Node, Port: ref.Port, Suggestions: closeMatches(ref.Port, node.GetInputs())}
+	}
+	return node, input, nil
+}
+
+// resetInput reverts a node's input to the default value of the node library
+// and removes the nodes of an expression that was bound to it.
+func (b *Builder) resetInput(node *ast.Node, input *ast.Input) *Builder {
+	b.clearInput(node, input)
+	return b.removeExprNodes(PortRef{Node: node.Name, Port: input.Name})
+}
+
+// clearInput reverts a node's input to the default value of the node library.
+func (b *Builder) clearInput(node *ast.Node, input *ast.Input) {
+	delete(b.InputsAlreadyConnected, node.Name+"."+input.Name)
+	input.Kind = ast.DependencyKind{External: &ast.External{}}
+	lib, ok := b.c.Nodes[node.OpName]
+	if !ok {
+		return
+	}
+	if libInput, ok := lib.GetInput(input.Name); ok {
+		input.Props = deepCopyProps(libInput.Props)
+		input.Kind.External = nil
+		if ext := libInput.Kind.External; ext != nil {
+			input.Kind.External = &ast.External{Promoted: ext.Promoted}
+		}
+	}
+}
+
+// forgetInputs forgets the assignments and connections of all of the node's inputs.
+func (b *Builder) forgetInputs(nodeName string) {
+	for _, input := range b.Nodes[nodeName].Inputs {
+		delete(b.InputsAlreadyConnected, nodeName+"."+input.Name)
+	}
+}
+
+// groupInstanceNodes adds the nodes that were created by instantiating the
+// named group instance, directly or within nested groups (e.g. both
+// "Helix.CoilPair.coils-1-2.wire" and "Helix.Coil.CoilPair.coils-1-2.left.wire"
+// are nodes of the "CoilPair.coils-1-2" instance), to nodes and the instance
+// itself and its nested instances to instances.
+func (b *Builder) groupInstanceNodes(instanceName string, nodes, instances map[string]bool) {
+	instances[instanceName] = true
+	for _, step := range b.groupInstances[instanceName].groupRecorder {
+		if step.action != "AddNode" {
+			continue
+		}
+		name := injectGroupName(step.node, instanceName)
+		if _, ok := b.groupInstances[name]; ok {
+			b.groupInstanceNodes(name, nodes, instances)
+		} else if _, ok := b.Nodes[name]; ok {
+			nodes[name] = true
+		}
+	}
+}
+
+// portNode returns the node name of a full port name (e.g. "Helix.wire-1" from "Helix.wire-1.out_mesh").
+func portNode(fullPortName string) string {
+	i := strings.LastIndex(fullPortName, ".")
+	if i < 0 {
+		return fullPortName
+	}
+	return fullPortName[:i]
+}
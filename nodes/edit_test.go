@@ -0,0 +1,171 @@
+package nodes
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gmlewis/go-bjk/ast"
+	"github.com/google/go-cmp/cmp"
+	lua "github.com/yuin/gopher-lua"
+)
+
+func TestBuilder_Disconnect(t *testing.T) {
+	b := fakeDesign(t)
+	b.Disconnect("MergeMeshes.m.mesh_a")
+	if _, ok := b.InputsAlreadyConnected["MergeMeshes.m.mesh_a"]; ok {
+		t.Error("MergeMeshes.m.mesh_a is still marked as connected")
+	}
+	b.Connect(b.Ref("Helix.a").Out("out_mesh"), "MergeMeshes.n.mesh_b")
+	if len(b.errs) > 0 {
+		t.Fatalf("unexpected errors: %v", b.errs)
+	}
+
+	want := []string{
+		"MergeMeshes.m.mesh_b <- Helix.b.out_mesh",
+		"MergeMeshes.n.mesh_a <- MergeMeshes.m.out_mesh",
+		"MergeMeshes.n.mesh_b <- Helix.a.out_mesh",
+	}
+	if diff := cmp.Diff(want, connections(b)); diff != "" {
+		t.Errorf("connections mismatch (-want +got):\n%v", diff)
+	}
+
+	b.Disconnect("MergeMeshes.m.mesh_a")
+	if len(b.errs) != 1 {
+		t.Errorf("disconnecting an unconnected input: got %v errors, want 1", len(b.errs))
+	}
+}
+
+func TestBuilder_RemoveNode(t *testing.T) {
+	b := fakeDesign(t)
+	b.RemoveNode("Helix.a")
+	if len(b.errs) > 0 {
+		t.Fatalf("unexpected errors: %v", b.errs)
+	}
+
+	if diff := cmp.Diff([]string{"Helix.b", "MergeMeshes.m", "MergeMeshes.n"}, b.NodeOrder); diff != "" {
+		t.Errorf("NodeOrder mismatch (-want +got):\n%v", diff)
+	}
+	want := []string{
+		"MergeMeshes.m.mesh_b <- Helix.b.out_mesh",
+		"MergeMeshes.n.mesh_a <- MergeMeshes.m.out_mesh",
+	}
+	if diff := cmp.Diff(want, connections(b)); diff != "" {
+		t.Errorf("connections mismatch (-want +got):\n%v", diff)
+	}
+	for k := range b.InputsAlreadyConnected {
+		if k == "Helix.a.segments" || k == "MergeMeshes.m.mesh_a" {
+			t.Errorf("InputsAlreadyConnected still has %q", k)
+		}
+	}
+
+	design, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(design.Graph.Nodes), 3; got != want {
+		t.Errorf("got %v nodes, want %v", got, want)
+	}
+}
+
+func TestBuilder_RemoveNode_SharedLabel(t *testing.T) {
+	b := fakeBuilder().
+		AddNode("Helix.wire-1").
+		AddNode("Spiral.wire-1").
+		AddNode("MakeScalar.x")
+	for _, name := range []string{"wire-1", "x", "Spiral"} {
+		b.RemoveNode(name)
+		var ue *UnknownNodeError
+		if len(b.errs) != 1 || !errors.As(b.errs[0], &ue) {
+			t.Errorf("RemoveNode(%q) errs = %v, want an *UnknownNodeError", name, b.errs)
+		}
+		b.errs = nil
+	}
+
+	b.RemoveNode("Spiral.wire-1")
+	if len(b.errs) > 0 {
+		t.Fatalf("unexpected errors: %v", b.errs)
+	}
+	if diff := cmp.Diff([]string{"Helix.wire-1", "MakeScalar.x"}, b.NodeOrder); diff != "" {
+		t.Errorf("NodeOrder mismatch (-want +got):\n%v", diff)
+	}
+}
+
+func TestBuilder_ReplaceNode(t *testing.T) {
+	b := fakeDesign(t)
+	b.ReplaceNode("Helix.a", "Spiral", Scalar("radius", 2))
+	if len(b.errs) > 0 {
+		t.Fatalf("unexpected errors: %v", b.errs)
+	}
+
+	if diff := cmp.Diff([]string{"Spiral.a", "Helix.b", "MergeMeshes.m", "MergeMeshes.n"}, b.NodeOrder); diff != "" {
+		t.Errorf("NodeOrder mismatch (-want +got):\n%v", diff)
+	}
+	if got, want := b.InputsAlreadyConnected["MergeMeshes.m.mesh_a"], "Spiral.a.out_mesh"; got != want {
+		t.Errorf("MergeMeshes.m.mesh_a connected to %q, want %q", got, want)
+	}
+	n := b.Nodes["Spiral.a"]
+	if got := n.Inputs[0].Props["default"]; got != lua.LNumber(24) {
+		t.Errorf("segments = %v, want 24", got)
+	}
+	if got := n.Inputs[1].Props["default"]; got != lua.LNumber(2) {
+		t.Errorf("radius = %v, want 2", got)
+	}
+
+	// A Helix has no mesh inputs, but its mesh output is still connected.
+	b.ReplaceNode("MergeMeshes.m", "Helix")
+	if len(b.errs) > 0 {
+		t.Fatalf("unexpected errors: %v", b.errs)
+	}
+	want := []string{"MergeMeshes.n.mesh_a <- Helix.m.out_mesh"}
+	if diff := cmp.Diff(want, connections(b)); diff != "" {
+		t.Errorf("connections mismatch (-want +got):\n%v", diff)
+	}
+}
+
+func TestBuilder_ReplaceGroupNode(t *testing.T) {
+	b := fakeBuilder().
+		NewGroup("Coil", func(b *Builder) *Builder {
+			return b.AddNode("Helix.h").
+				Input("segments", "Helix.h.segments").
+				Input("size", "Helix.h.size").
+				Output("Helix.h.out_mesh", "out_mesh")
+		}).
+		AddNode("Coil.c", Scalar("segments", 8)).
+		ReplaceNode("Helix.Coil.c.h", "Spiral")
+	b.CheckUnusedGroupInputs = true
+
+	// The assigned segments input is kept, and Spiral has no size input to leave unused.
+	if _, err := b.Build(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := b.InputsAlreadyConnected["Spiral.Coil.c.h.segments"], "8"; got != want {
+		t.Errorf("segments = %q, want %q", got, want)
+	}
+}
+
+func TestBuilder_SetInput(t *testing.T) {
+	b := fakeDesign(t)
+	b.SetInput("Helix.a", Scalar("segments", 12), "direction=Clockwise", "node_position=(10,20)").
+		SetInput("MergeMeshes.m", "bogus=1")
+	if len(b.errs) != 1 {
+		t.Fatalf("got %v errors, want 1: %v", len(b.errs), b.errs)
+	}
+
+	n := b.Nodes["Helix.a"]
+	if got := n.Inputs[1].Props["default"]; got != lua.LNumber(12) {
+		t.Errorf("segments = %v, want 12", got)
+	}
+	if got := b.InputsAlreadyConnected["Helix.a.direction"]; got != "Clockwise" {
+		t.Errorf("direction = %q, want Clockwise", got)
+	}
+	if diff := cmp.Diff(&ast.Vec2{X: 10, Y: 20}, n.NodePosition); diff != "" {
+		t.Errorf("NodePosition mismatch (-want +got):\n%v", diff)
+	}
+
+	b.errs = nil
+	b.SetInput("Helix.a", Scalar("segments", 1))
+	var ce *ConstraintError
+	if len(b.errs) != 1 || !errors.As(b.errs[0], &ce) {
+		t.Errorf("errs = %v, want a *ConstraintError", b.errs)
+	}
+}
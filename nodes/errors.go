@@ -0,0 +1,229 @@
+package nodes
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// BuildError is returned by Build when errors were found while building
+// a design. Its message shows at most the first 5 errors (unless debugging),
+// but all of them are available in Errs and to errors.Is and errors.As.
+type BuildError struct {
+	Errs []error
+
+	showAll bool
+}
+
+func (e *BuildError) Error() string {
+	if e.showAll || len(e.Errs) <= 5 {
+		return fmt.Sprintf("%v ERRORS FOUND:\n%v", len(e.Errs), errors.Join(e.Errs...))
+	}
+	return fmt.Sprintf("%v ERRORS FOUND - HERE ARE THE FIRST 5:\n%v", len(e.Errs), errors.Join(e.Errs[:5]...))
+}
+
+func (e *BuildError) Unwrap() []error { return e.Errs }
+
+// Errors returns all errors found so far while building the design.
+func (b *Builder) Errors() []error {
+	return append([]error(nil), b.errs...)
+}
+
+// UnknownNodeTypeError reports a node whose type is neither in the node
+// library nor a group.
+type UnknownNodeTypeError struct {
+	// Op is the Builder call that failed (e.g. "AddNode").
+	Op string
+	// Node is the full name of the node.
+	Node string
+	// Type is the unknown type.
+	Type string
+	// Suggestions are the closest known node types and groups.
+	Suggestions []string
+}
+
+func (e *UnknownNodeTypeError) Error() string {
+	return fmt.Sprintf("%v: unknown node type '%v'%v", e.Op, e.Type, didYouMean(e.Suggestions))
+}
+
+// UnknownNodeError reports a reference to a node that is not in the design.
+type UnknownNodeError struct {
+	// Op is the Builder call that failed (e.g. `Connect("Helix.a.out_mesh", "MergeMeshes.m.mesh_a")`).
+	Op string
+	// Node is the unknown full node name.
+	Node string
+	// Suggestions are the closest names of nodes in the design.
+	Suggestions []string
+}
+
+func (e *UnknownNodeError) Error() string {
+	return fmt.Sprintf("%v: unknown node '%v'%v", e.Op, e.Node, didYouMean(e.Suggestions))
+}
+
+// UnknownPortError reports a reference to an input or output port that a node does not have.
+type UnknownPortError struct {
+	// Op is the Builder call that failed.
+	Op string
+	// Node is the full name of the node.
+	Node string
+	// Port is the unknown port name.
+	Port string
+	// Output is true if Port was expected to be an output port.
+	Output bool
+	// Suggestions are the closest names of the node's ports.
+	Suggestions []string
+}
+
+func (e *UnknownPortError) Error() string {
+	kind := "input"
+	if e.Output {
+		kind = "output"
+	}
+	return fmt.Sprintf("%v: node '%v' has no %v port %q%v", e.Op, e.Node, kind, e.Port, didYouMean(e.Suggestions))
+}
+
+// TypeMismatchError reports a connection between ports of different data types.
+type TypeMismatchError struct {
+	// Op is the Builder call that failed.
+	Op string
+	// From and To are the connected ports.
+	From, To PortRef
+	// FromType and ToType are their data types.
+	FromType, ToType string
+	// Suggestions are the input ports of the 'to' node that accept FromType
+	// and are not yet connected, closest names first.
+	Suggestions []string
+}
+
+func (e *TypeMismatchError) Error() string {
+	return fmt.Sprintf("%v: 'from' node type '%v' not compatible with 'to' node type '%v'%v", e.Op, e.FromType, e.ToType, didYouMean(e.Suggestions))
+}
+
+// AlreadyConnectedError reports an input port that is already connected or statically assigned.
+type AlreadyConnectedError struct {
+	// Op is the Builder call that failed.
+	Op string
+	// Node is the full name of the node.
+	Node string
+	// Port is the input port name.
+	Port string
+	// ConnectedTo is the port connected to the input or the value assigned to it.
+	ConnectedTo string
+	// Suggestions are inputs of the node of the same data type that are
+	// not yet connected, closest names first.
+	Suggestions []string
+}
+
+func (e *AlreadyConnectedError) Error() string {
+	return fmt.Sprintf("%v: input '%v.%v' already connected OR statically assigned to %q%v", e.Op, e.Node, e.Port, e.ConnectedTo, didYouMean(e.Suggestions))
+}
+
+func didYouMean(suggestions []string) string {
+	switch len(suggestions) {
+	case 0:
+		return ""
+	case 1:
+		return fmt.Sprintf("; did you mean %q?", suggestions[0])
+	}
+	quoted := make([]string, 0, len(suggestions))
+	for _, s := range suggestions {
+		quoted = append(quoted, fmt.Sprintf("%q", s))
+	}
+	return fmt.Sprintf("; did you mean one of: %v?", strings.Join(quoted, ", "))
+}
+
+const maxSuggestions = 5
+
+// closeMatches returns the candidates that are close to name, closest first.
+func closeMatches(name string, candidates []string) []string {
+	maxDist := max(2, len(name)/3)
+	lower := strings.ToLower(name)
+	var result []string
+	for _, c := range rankByDistance(name, candidates) {
+		lc := strings.ToLower(c)
+		if editDistance(lower, lc) <= maxDist || (len(lower) > 2 && strings.Contains(lc, lower)) {
+			result = append(result, c)
+		}
+	}
+	if len(result) > maxSuggestions {
+		result = result[:maxSuggestions]
+	}
+	return result
+}
+
+// rankByDistance returns the unique candidates sorted by their
+// (case-insensitive) edit distance from name, then by name.
+func rankByDistance(name string, candidates []string) []string {
+	lower := strings.ToLower(name)
+	dist := map[string]int{}
+	for _, c := range candidates {
+		dist[c] = editDistance(lower, strings.ToLower(c))
+	}
+	result := make([]string, 0, len(dist))
+	for c := range dist {
+		result = append(result, c)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if dist[result[i]] != dist[result[j]] {
+			return dist[result[i]] < dist[result[j]]
+		}
+		return result[i] < result[j]
+	})
+	return result
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+// nodeTypeNames returns the names of all node types and groups known to the builder.
+func (b *Builder) nodeTypeNames() []string {
+	result := make([]string, 0, len(b.c.Nodes)+len(b.Groups))
+	for name := range b.c.Nodes {
+		result = append(result, name)
+	}
+	for name := range b.Groups {
+		result = append(result, name)
+	}
+	return result
+}
+
+// freeInputs returns the inputs of the node of the given data type that are
+// neither connected nor assigned, ranked by their distance from port.
+func (b *Builder) freeInputs(nodeName, port, dataType string) []string {
+	node, ok := b.Nodes[nodeName]
+	if !ok {
+		return nil
+	}
+	var candidates []string
+	for _, input := range node.Inputs {
+		if _, ok := b.InputsAlreadyConnected[nodeName+"."+input.Name]; ok || input.DataType != dataType {
+			continue
+		}
+		candidates = append(candidates, input.Name)
+	}
+	result := rankByDistance(port, candidates)
+	if len(result) > maxSuggestions {
+		result = result[:maxSuggestions]
+	}
+	return result
+}
@@ -0,0 +1,160 @@
+package nodes
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "", b: "", want: 0},
+		{a: "Helix", b: "Helix", want: 0},
+		{a: "Helx", b: "Helix", want: 1},
+		{a: "kitten", b: "sitting", want: 3},
+		{a: "", b: "abc", want: 3},
+	}
+
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestCloseMatches(t *testing.T) {
+	candidates := []string{"Helix", "MakeScalar", "MergeMeshes", "MakeQuad", "ExtrudeAlongCurve"}
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{name: "helix", want: []string{"Helix"}},
+		{name: "MakeScaler", want: []string{"MakeScalar"}},
+		{name: "Merge", want: []string{"MergeMeshes"}},
+		{name: "Extrude", want: []string{"ExtrudeAlongCurve"}},
+		{name: "Cube", want: nil},
+	}
+
+	for _, tt := range tests {
+		if diff := cmp.Diff(tt.want, closeMatches(tt.name, candidates)); diff != "" {
+			t.Errorf("closeMatches(%q) mismatch (-want +got):\n%v", tt.name, diff)
+		}
+	}
+}
+
+func TestBuilder_StructuredErrors(t *testing.T) {
+	c := &Client{Nodes: fakeLibrary()}
+	newBuilder := func() *Builder {
+		return c.NewBuilder().
+			AddNode("MakeScalar.s").
+			AddNode("Helix.h").
+			AddNode("MergeMeshes.m").
+			Connect("Helix.h.out_mesh", "MergeMeshes.m.mesh_a")
+	}
+
+	t.Run("UnknownNodeTypeError", func(t *testing.T) {
+		b := newBuilder().AddNode("Helx.a")
+		var got *UnknownNodeTypeError
+		if !errors.As(b.errs[0], &got) {
+			t.Fatalf("errs = %v, want *UnknownNodeTypeError", b.errs)
+		}
+		want := &UnknownNodeTypeError{Op: "AddNode", Node: "Helx.a", Type: "Helx", Suggestions: []string{"Helix"}}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("error mismatch (-want +got):\n%v", diff)
+		}
+		if !strings.HasSuffix(got.Error(), `; did you mean "Helix"?`) {
+			t.Errorf("Error() = %q, want a suggestion", got.Error())
+		}
+	})
+
+	t.Run("UnknownNodeError", func(t *testing.T) {
+		b := newBuilder().Connect("Helix.h.out_mesh", "MergeMeshes.n.mesh_b")
+		var got *UnknownNodeError
+		if !errors.As(b.errs[0], &got) {
+			t.Fatalf("errs = %v, want *UnknownNodeError", b.errs)
+		}
+		if diff := cmp.Diff([]string{"MergeMeshes.m"}, got.Suggestions); diff != "" {
+			t.Errorf("Suggestions mismatch (-want +got):\n%v", diff)
+		}
+	})
+
+	t.Run("UnknownPortError", func(t *testing.T) {
+		b := newBuilder().
+			Connect("Helix.h.out_mesj", "MergeMeshes.m.mesh_b").
+			AddNode("Helix.i", "segmnts=3")
+		if len(b.errs) != 2 {
+			t.Fatalf("got %v errors, want 2: %v", len(b.errs), b.errs)
+		}
+		want := []*UnknownPortError{
+			{Op: `Connect("Helix.h.out_mesj", "MergeMeshes.m.mesh_b")`, Node: "Helix.h", Port: "out_mesj", Output: true, Suggestions: []string{"out_mesh"}},
+			{Op: "AddNode", Node: "Helix.i", Port: "segmnts", Suggestions: []string{"segments"}},
+		}
+		for i, err := range b.errs {
+			var got *UnknownPortError
+			if !errors.As(err, &got) {
+				t.Fatalf("errs[%v] = %v, want *UnknownPortError", i, err)
+			}
+			if diff := cmp.Diff(want[i], got); diff != "" {
+				t.Errorf("errs[%v] mismatch (-want +got):\n%v", i, diff)
+			}
+		}
+	})
+
+	t.Run("TypeMismatchError", func(t *testing.T) {
+		b := newBuilder().Connect("MakeScalar.s.x", "Helix.h.size")
+		var got *TypeMismatchError
+		if !errors.As(b.errs[0], &got) {
+			t.Fatalf("errs = %v, want *TypeMismatchError", b.errs)
+		}
+		if got.FromType != "scalar" || got.ToType != "vec3" {
+			t.Errorf("types = %v -> %v, want scalar -> vec3", got.FromType, got.ToType)
+		}
+		if diff := cmp.Diff([]string{"segments", "start_angle"}, got.Suggestions); diff != "" {
+			t.Errorf("Suggestions mismatch (-want +got):\n%v", diff)
+		}
+	})
+
+	t.Run("AlreadyConnectedError", func(t *testing.T) {
+		b := newBuilder().Connect("Helix.h.out_mesh", "MergeMeshes.m.mesh_a")
+		var got *AlreadyConnectedError
+		if !errors.As(b.errs[0], &got) {
+			t.Fatalf("errs = %v, want *AlreadyConnectedError", b.errs)
+		}
+		want := &AlreadyConnectedError{
+			Op:          `Connect("Helix.h.out_mesh", "MergeMeshes.m.mesh_a")`,
+			Node:        "MergeMeshes.m",
+			Port:        "mesh_a",
+			ConnectedTo: "Helix.h.out_mesh",
+			Suggestions: []string{"mesh_b"},
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("error mismatch (-want +got):\n%v", diff)
+		}
+	})
+
+	t.Run("BuildError", func(t *testing.T) {
+		b := newBuilder()
+		for range 6 {
+			b.AddNode("Cube")
+		}
+		b.Connect("Helix.h.out_mesh", "MergeMeshes.m.mesh_a")
+		_, err := b.Build()
+		var buildErr *BuildError
+		if !errors.As(err, &buildErr) || len(buildErr.Errs) != 7 {
+			t.Fatalf("Build err = %v, want a *BuildError with 7 errors", err)
+		}
+		if !strings.Contains(err.Error(), "HERE ARE THE FIRST 5") {
+			t.Errorf("Build err = %v, want only the first 5 errors", err)
+		}
+		// errors.As finds errors beyond the first 5.
+		var ace *AlreadyConnectedError
+		if !errors.As(err, &ace) {
+			t.Errorf("errors.As(%v) did not find the *AlreadyConnectedError", err)
+		}
+	})
+}
@@ -20,6 +20,11 @@ func (c *Client) Eval(design *ast.BJK) (*Mesh, error) {
 	}
 	nodes := design.Graph.Nodes
 
+	// runNode recurses over the connections, so refuse to evaluate a cyclic graph.
+	if cycle := design.Graph.FindCycle(); cycle != nil {
+		return nil, &ast.CycleError{Path: cycle}
+	}
+
 	// Generate a lookup table for external parameters. key="nodeIdx,paramName" (e.g. "0,center")
 	c.extParamsLookup = map[string]*ast.ValueEnum{}
 	for _, pv := range design.Graph.ExternalParameters.ParamValues {
@@ -99,15 +104,17 @@ func (c *Client) runNode(nodes []*ast.Node, targetNodeIdx int) error {
 			if c.debug {
 				log.Printf("runNode: external ValueEnum=%#v", *ve)
 			}
-			lval := valueEnumToLValue(c.ls, ve)
+			lval, err := c.checkEvalInput(targetNodeIdx, targetNode, input.Name, valueEnumToLValue(c.ls, ve))
+			if err != nil {
+				return err
+			}
 			input.Props[input.Name] = lval
 			inputsTable.RawSet(lua.LString(input.Name), lval)
 			if c.debug {
 				log.Printf("Setting node %q input %q to %v", targetNode.OpName, input.Name, lval)
 			}
-			// TODO: honor properties like min, max, soft_max, default, num_decimals, etc?
 			if _, ok := nameToKey[input.Name]; !ok {
-				log.Printf("WARNING! setting lua input %q on node %q but it is no longer declared as one of its inputs!", input.Name, targetNode.OpName)
+				log.Printf("WARNING! setting lua input %q on node %q but it is no longer declared as one of its inputs! (see bjk-migrate)", input.Name, targetNode.OpName)
 			}
 			continue
 		}
@@ -126,9 +133,8 @@ func (c *Client) runNode(nodes []*ast.Node, targetNodeIdx int) error {
 			if c.debug {
 				log.Printf("Setting node %q input %q to %v", targetNode.OpName, input.Name, lVal)
 			}
-			// TODO: honor properties like min, max, soft_max, default, num_decimals, etc?
 			if _, ok := nameToKey[input.Name]; !ok {
-				log.Printf("WARNING! setting lua input %q on node %q but it is no longer declared as one of its inputs!", input.Name, targetNode.OpName)
+				log.Printf("WARNING! setting lua input %q on node %q but it is no longer declared as one of its inputs! (see bjk-migrate)", input.Name, targetNode.OpName)
 			}
 			continue
 		}
@@ -169,13 +175,15 @@ func (c *Client) runNode(nodes []*ast.Node, targetNodeIdx int) error {
 		if lVal == nil {
 			return fmt.Errorf("programming error: lVal remains unset for input %#v", *input)
 		}
+		if lVal, err = c.checkEvalInput(targetNodeIdx, targetNode, input.Name, lVal); err != nil {
+			return err
+		}
 		inputsTable.RawSet(lua.LString(input.Name), lVal)
 		if c.debug {
 			log.Printf("Setting node %q input %q to %v", targetNode.OpName, input.Name, lVal)
 		}
-		// TODO: honor properties like min, max, soft_max, default, num_decimals, etc?
 		if _, ok := nameToKey[input.Name]; !ok {
-			log.Printf("WARNING! setting lua input %q on node %q but it is no longer declared as one of its inputs!", input.Name, targetNode.OpName)
+			log.Printf("WARNING! setting lua input %q on node %q but it is no longer declared as one of its inputs! (see bjk-migrate)", input.Name, targetNode.OpName)
 		}
 	}
 
@@ -281,6 +289,8 @@ func (c *Client) genNumToKeyMap(targetNode *ast.Node) (map[string]string, error)
 }
 
 // GetScalar gets the value of a scalar from a design and returns it.
+// nodeName is the node's full name followed by a dot and the output name
+// (e.g. "HerringboneGear.gear-1.pitch_radius").
 func (c *Client) GetScalar(design *ast.BJK, nodeName string) (float64, error) {
 	if design == nil || design.Graph == nil {
 		return 0, errors.New("design missing graph")
@@ -294,19 +304,32 @@ func (c *Client) GetScalar(design *ast.BJK, nodeName string) (float64, error) {
 		c.cachedMesh = mesh
 	}
 
-	parts := strings.Split(nodeName, ".")
-	if len(parts) != 2 {
-		return 0, fmt.Errorf("GetScalar - want nodeName in 2 parts, got: %+v", parts)
+	v, err := getOutput(design, nodeName)
+	if err != nil {
+		return 0, err
 	}
-	opName, outputName := parts[0], parts[1]
-	for _, n := range design.Graph.Nodes {
-		if n.OpName == opName {
-			v, ok := n.EvalOutputs[outputName]
-			if ok {
-				return float64(lua.LVAsNumber(v)), nil
-			}
-		}
+	return float64(lua.LVAsNumber(v)), nil
+}
+
+// getOutput returns the evaluated output named by the node's full name
+// followed by a dot and the output name (e.g. "HerringboneGear.gear-1.pitch_radius").
+// A node's op name may be used instead of its full name if it is unique in the design
+// (e.g. "HerringboneGear.pitch_radius").
+func getOutput(design *ast.BJK, fullName string) (lua.LValue, error) {
+	i := strings.LastIndex(fullName, ".")
+	if i < 0 {
+		return nil, fmt.Errorf("want output name in the form 'node.output', got: %q", fullName)
 	}
+	nodeName, outputName := fullName[:i], fullName[i+1:]
 
-	return 0, fmt.Errorf("output node '%v' not found", nodeName)
+	idx, err := design.Graph.FindNode(nodeName)
+	if err != nil {
+		return nil, fmt.Errorf("output node '%v': %w", fullName, err)
+	}
+	n := design.Graph.Nodes[idx]
+	v, ok := n.EvalOutputs[outputName]
+	if !ok {
+		return nil, fmt.Errorf("output node '%v' not found, choices are: %+v", fullName, maps.Keys(n.EvalOutputs))
+	}
+	return v, nil
 }
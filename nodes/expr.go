@@ -0,0 +1,424 @@
+package nodes
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gmlewis/go-bjk/ast"
+	"golang.org/x/exp/maps"
+)
+
+var paramNameRE = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// Param adds a design-level parameter as a 'MakeScalar' node named
+// "MakeScalar.<name>" with the given value. The args of nodes added later
+// can then refer to it in expressions like "size=vector(${inner_r}+${wire_width}/2,0,1)"
+// or "segments=${segments}*2". Each expression is compiled into 'ScalarMath'
+// (and, for vectors, 'MakeVector') nodes that are connected to the node's
+// input, so that the design remains parametric in Blackjack. These nodes are
+// named after the input (e.g. "ScalarMath.Helix.1.size-1") and are removed
+// along with the node by RemoveNode or replaced by SetInput.
+// Expressions support numbers, parameters, +, -, *, /, and parentheses.
+func (b *Builder) Param(name string, value float64) *Builder {
+	if b.isGroup {
+		b.errs = append(b.errs, fmt.Errorf("Param(%q) cannot be used within a group", name))
+		return b
+	}
+	if !paramNameRE.MatchString(name) {
+		b.errs = append(b.errs, fmt.Errorf("Param(%q): bad parameter name", name))
+		return b
+	}
+	if _, ok := b.params[name]; ok {
+		b.errs = append(b.errs, fmt.Errorf("Param(%q): parameter already defined", name))
+		return b
+	}
+	inputs, outputs := b.libPorts("MakeScalar", "scalar")
+	if len(inputs) < 1 || len(outputs) < 1 {
+		b.errs = append(b.errs, fmt.Errorf("Param(%q): node library has no 'MakeScalar' node with a scalar input and output", name))
+		return b
+	}
+
+	nodeName := "MakeScalar." + name
+	before := len(b.errs)
+	b = b.addNode(nodeName, []Arg{Scalar(inputs[0], value)})
+	if len(b.errs) > before {
+		return b
+	}
+	if b.params == nil {
+		b.params = map[string]PortRef{}
+	}
+	b.params[name] = PortRef{Node: nodeName, Port: outputs[0]}
+	return b
+}
+
+// libPorts returns the names of the inputs and outputs of the given data
+// type of a node in the node library.
+func (b *Builder) libPorts(nodeType, dataType string) (inputs, outputs []string) {
+	n, ok := b.c.Nodes[nodeType]
+	if !ok {
+		return nil, nil
+	}
+	for _, in := range n.Inputs {
+		if in.DataType == dataType {
+			inputs = append(inputs, in.Name)
+		}
+	}
+	for _, out := range n.Outputs {
+		if out.DataType == dataType {
+			outputs = append(outputs, out.Name)
+		}
+	}
+	return inputs, outputs
+}
+
+// splitExprArgs separates the args of a new library node n whose values
+// are expressions that refer to parameters from the other args.
+func splitExprArgs(n *ast.Node, args []Arg) (plain, exprs []Arg) {
+	for _, arg := range args {
+		if arg.kind != argString || !strings.Contains(arg.s, "${") {
+			plain = append(plain, arg)
+			continue
+		}
+		if input, ok := n.GetInput(arg.Name); ok && (input.DataType == "scalar" || input.DataType == "vec3") {
+			exprs = append(exprs, arg)
+			continue
+		}
+		plain = append(plain, arg)
+	}
+	return plain, exprs
+}
+
+// bindExpr compiles the expression of arg and connects it to the input of the named node.
+func (b *Builder) bindExpr(nodeName string, arg Arg) *Builder {
+	to := PortRef{Node: nodeName, Port: arg.Name}
+	input, ok := b.Nodes[nodeName].GetInput(arg.Name)
+	if !ok {
+		b.errs = append(b.errs, &UnknownPortError{Op: fmt.Sprintf("AddNode(%q)", nodeName), Node: nodeName, Port: arg.Name})
+		return b
+	}
+	errFn := func(err error) *Builder {
+		b.errs = append(b.errs, fmt.Errorf("%v: expression %q: %w", to, arg.s, err))
+		return b
+	}
+
+	src := strings.TrimSpace(arg.s)
+	ec := &exprCompiler{b: b, label: nodeName + "." + arg.Name}
+	if input.DataType == "scalar" {
+		e, err := parseExpr(src)
+		if err != nil {
+			return errFn(err)
+		}
+		x, port, err := ec.compile(e)
+		if err != nil {
+			return errFn(err)
+		}
+		if port == nil {
+			if err := Scalar(arg.Name, x).set(input); err != nil {
+				return errFn(err)
+			}
+			b.InputsAlreadyConnected[to.String()] = formatFloat(x)
+			return b
+		}
+		return b.connect(*port, to)
+	}
+
+	if !strings.HasPrefix(src, "vector(") || !strings.HasSuffix(src, ")") {
+		return errFn(fmt.Errorf("want vector(x,y,z)"))
+	}
+	components := splitTopLevel(src[len("vector(") : len(src)-1])
+	if len(components) != 3 {
+		return errFn(fmt.Errorf("want 3 vector components, got %v", len(components)))
+	}
+	xyz, _ := b.libPorts("MakeVector", "scalar")
+	_, vecOutputs := b.libPorts("MakeVector", "vec3")
+	if len(xyz) < 3 || len(vecOutputs) < 1 {
+		return errFn(fmt.Errorf("node library has no 'MakeVector' node with 3 scalar inputs and a vector output"))
+	}
+
+	var args []Arg
+	var ports []*PortRef
+	for i, component := range components {
+		e, err := parseExpr(strings.TrimSpace(component))
+		if err != nil {
+			return errFn(err)
+		}
+		x, port, err := ec.compile(e)
+		if err != nil {
+			return errFn(err)
+		}
+		if port == nil {
+			args = append(args, Scalar(xyz[i], x))
+		}
+		ports = append(ports, port)
+	}
+
+	vecNode := "MakeVector." + ec.label
+	before := len(b.errs)
+	if b = b.addNode(vecNode, args); len(b.errs) > before {
+		return b
+	}
+	for i, port := range ports {
+		if port != nil {
+			b = b.connect(*port, PortRef{Node: vecNode, Port: xyz[i]})
+		}
+	}
+	return b.connect(PortRef{Node: vecNode, Port: vecOutputs[0]}, to)
+}
+
+// removeExprNodes removes the nodes compiled from an earlier expression bound to the input.
+func (b *Builder) removeExprNodes(to PortRef) *Builder {
+	for _, nodeName := range b.exprNodes(to) {
+		// Removing one node of the expression may have removed the others.
+		if _, ok := b.Nodes[nodeName]; ok {
+			b = b.RemoveNode(nodeName)
+		}
+	}
+	return b
+}
+
+// exprNodes returns the names of the nodes compiled from an expression bound to the input.
+func (b *Builder) exprNodes(to PortRef) []string {
+	label := to.String()
+	var result []string
+	for _, nodeName := range b.NodeOrder {
+		t := nodeType(nodeName)
+		rest := strings.TrimPrefix(nodeName, t+".")
+		if (t == "MakeVector" && rest == label) || (t == "ScalarMath" && isExprNodeLabel(rest, label)) {
+			result = append(result, nodeName)
+		}
+	}
+	return result
+}
+
+// isExprNodeLabel reports whether s is label followed by "-<n>".
+func isExprNodeLabel(s, label string) bool {
+	n, ok := strings.CutPrefix(s, label+"-")
+	if !ok || n == "" {
+		return false
+	}
+	_, err := strconv.Atoi(n)
+	return err == nil
+}
+
+// splitTopLevel splits s at the commas that are not within parentheses.
+func splitTopLevel(s string) []string {
+	var result []string
+	var depth, start int
+	for i, r := range s {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				result = append(result, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(result, s[start:])
+}
+
+// expr is a parsed expression: a number, a parameter, or a binary operation.
+type expr struct {
+	op       byte // '+', '-', '*', or '/'; 0 for a number or parameter
+	x        float64
+	param    string
+	lhs, rhs *expr
+}
+
+// parseExpr parses an expression made of numbers, ${name} parameters,
+// the binary operators +, -, *, and /, unary minus, and parentheses.
+func parseExpr(s string) (*expr, error) {
+	p := &exprParser{s: s}
+	e, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	if p.skipSpace(); p.pos < len(p.s) {
+		return nil, fmt.Errorf("unexpected %q at offset %v", p.s[p.pos:], p.pos)
+	}
+	return e, nil
+}
+
+type exprParser struct {
+	s   string
+	pos int
+}
+
+func (p *exprParser) skipSpace() {
+	for p.pos < len(p.s) && p.s[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+// peek returns the next non-space byte or 0 at the end.
+func (p *exprParser) peek() byte {
+	if p.skipSpace(); p.pos < len(p.s) {
+		return p.s[p.pos]
+	}
+	return 0
+}
+
+func (p *exprParser) parseSum() (*expr, error) {
+	lhs, err := p.parseProduct()
+	if err != nil {
+		return nil, err
+	}
+	for op := p.peek(); op == '+' || op == '-'; op = p.peek() {
+		p.pos++
+		rhs, err := p.parseProduct()
+		if err != nil {
+			return nil, err
+		}
+		lhs = &expr{op: op, lhs: lhs, rhs: rhs}
+	}
+	return lhs, nil
+}
+
+func (p *exprParser) parseProduct() (*expr, error) {
+	lhs, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for op := p.peek(); op == '*' || op == '/'; op = p.peek() {
+		p.pos++
+		rhs, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		lhs = &expr{op: op, lhs: lhs, rhs: rhs}
+	}
+	return lhs, nil
+}
+
+func (p *exprParser) parseUnary() (*expr, error) {
+	switch p.peek() {
+	case '-':
+		p.pos++
+		e, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &expr{op: '-', lhs: &expr{}, rhs: e}, nil
+	case '+':
+		p.pos++
+		return p.parseUnary()
+	case '(':
+		p.pos++
+		e, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ')' {
+			return nil, fmt.Errorf("missing ')' at offset %v", p.pos)
+		}
+		p.pos++
+		return e, nil
+	case '$':
+		if !strings.HasPrefix(p.s[p.pos:], "${") {
+			break
+		}
+		end := strings.IndexByte(p.s[p.pos:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("missing '}' at offset %v", p.pos)
+		}
+		name := p.s[p.pos+2 : p.pos+end]
+		p.pos += end + 1
+		return &expr{param: name}, nil
+	case 0:
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+
+	start := p.pos
+	for p.pos < len(p.s) && strings.IndexByte("0123456789.eE", p.s[p.pos]) >= 0 {
+		if (p.s[p.pos] == 'e' || p.s[p.pos] == 'E') && p.pos+1 < len(p.s) && (p.s[p.pos+1] == '-' || p.s[p.pos+1] == '+') {
+			p.pos++
+		}
+		p.pos++
+	}
+	x, err := strconv.ParseFloat(p.s[start:p.pos], 64)
+	if err != nil || start == p.pos {
+		return nil, fmt.Errorf("unexpected %q at offset %v", p.s[start:], start)
+	}
+	return &expr{x: x}, nil
+}
+
+// exprCompiler compiles expressions into 'ScalarMath' nodes named after a label.
+type exprCompiler struct {
+	b     *Builder
+	label string
+	n     int
+}
+
+var scalarMathOps = map[byte]string{'+': "Add", '-': "Sub", '*': "Mul", '/': "Div"}
+
+// compile returns either the constant value of e or the output port that computes it.
+func (ec *exprCompiler) compile(e *expr) (float64, *PortRef, error) {
+	b := ec.b
+	switch {
+	case e.param != "":
+		port, ok := b.params[e.param]
+		if !ok {
+			return 0, nil, fmt.Errorf("unknown parameter ${%v}%v", e.param, didYouMean(closeMatches(e.param, maps.Keys(b.params))))
+		}
+		return 0, &port, nil
+	case e.op == 0:
+		return e.x, nil, nil
+	}
+
+	x, xPort, err := ec.compile(e.lhs)
+	if err != nil {
+		return 0, nil, err
+	}
+	y, yPort, err := ec.compile(e.rhs)
+	if err != nil {
+		return 0, nil, err
+	}
+	if xPort == nil && yPort == nil {
+		switch e.op {
+		case '+':
+			return x + y, nil, nil
+		case '-':
+			return x - y, nil, nil
+		case '*':
+			return x * y, nil, nil
+		}
+		if y == 0 {
+			return 0, nil, fmt.Errorf("division by zero")
+		}
+		return x / y, nil, nil
+	}
+
+	inputs, outputs := b.libPorts("ScalarMath", "scalar")
+	ops, _ := b.libPorts("ScalarMath", "enum")
+	if len(inputs) < 2 || len(outputs) < 1 || len(ops) < 1 {
+		return 0, nil, fmt.Errorf("node library has no 'ScalarMath' node with an op, 2 scalar inputs, and a scalar output")
+	}
+	ec.n++
+	nodeName := fmt.Sprintf("ScalarMath.%v-%v", ec.label, ec.n)
+	args := []Arg{Enum(ops[0], scalarMathOps[e.op])}
+	if xPort == nil {
+		args = append(args, Scalar(inputs[0], x))
+	}
+	if yPort == nil {
+		args = append(args, Scalar(inputs[1], y))
+	}
+	before := len(b.errs)
+	if b.addNode(nodeName, args); len(b.errs) > before {
+		err := errors.Join(b.errs[before:]...)
+		b.errs = b.errs[:before]
+		return 0, nil, err
+	}
+	if xPort != nil {
+		b.connect(*xPort, PortRef{Node: nodeName, Port: inputs[0]})
+	}
+	if yPort != nil {
+		b.connect(*yPort, PortRef{Node: nodeName, Port: inputs[1]})
+	}
+	return 0, &PortRef{Node: nodeName, Port: outputs[0]}, nil
+}
@@ -0,0 +1,251 @@
+package nodes
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	lua "github.com/yuin/gopher-lua"
+)
+
+func (e *expr) sexpr() string {
+	switch {
+	case e.param != "":
+		return "$" + e.param
+	case e.op == 0:
+		return formatFloat(e.x)
+	}
+	return fmt.Sprintf("(%c %v %v)", e.op, e.lhs.sexpr(), e.rhs.sexpr())
+}
+
+func TestParseExpr(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr string
+	}{
+		{in: "${a}", want: "$a"},
+		{in: "1.5e-1", want: "0.15"},
+		{in: "${a}+${b}/2", want: "(+ $a (/ $b 2))"},
+		{in: "(${a} + ${b}) / 2", want: "(/ (+ $a $b) 2)"},
+		{in: "${a}-${b}-1", want: "(- (- $a $b) 1)"},
+		{in: "-${a}*2", want: "(* (- 0 $a) 2)"},
+		{in: "${a", wantErr: "missing '}'"},
+		{in: "${a}+", wantErr: "unexpected end of expression"},
+		{in: "(${a}", wantErr: "missing ')'"},
+		{in: "${a} 2", wantErr: `unexpected "2" at offset 5`},
+		{in: "$a", wantErr: `unexpected "$a" at offset 0`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			e, err := parseExpr(tt.in)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parseExpr err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := e.sexpr(); got != tt.want {
+				t.Errorf("parseExpr = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParam_Scalar(t *testing.T) {
+	b := fakeParamBuilder().
+		AddNode("Helix.1", "start_angle=${inner_r}", "segments=${wire_width}*(2+4)")
+	if _, err := b.Build(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"Helix.1.start_angle <- MakeScalar.inner_r.x",
+		"Helix.1.segments <- ScalarMath.Helix.1.segments-1.out",
+		"ScalarMath.Helix.1.segments-1.x <- MakeScalar.wire_width.x",
+	}
+	if diff := cmp.Diff(want, connections(b)); diff != "" {
+		t.Errorf("connections mismatch (-want +got):\n%v", diff)
+	}
+	if diff := cmp.Diff(map[string]float64{"y": 6}, scalarValues(b, "ScalarMath.Helix.1.segments-1")); diff != "" {
+		t.Errorf("ScalarMath values mismatch (-want +got):\n%v", diff)
+	}
+	if got := b.Nodes["ScalarMath.Helix.1.segments-1"].Inputs[0].Props["selected"]; got != lua.LNumber(2) {
+		t.Errorf("ScalarMath op selected = %v, want 2 (Mul)", got)
+	}
+	if diff := cmp.Diff(map[string]float64{"x": 3}, scalarValues(b, "MakeScalar.inner_r")); diff != "" {
+		t.Errorf("MakeScalar values mismatch (-want +got):\n%v", diff)
+	}
+}
+
+func TestParam_Vector(t *testing.T) {
+	b := fakeParamBuilder().
+		AddNode("Helix.1", "size=vector(${inner_r}+${wire_width}/2, 2*3, -${inner_r})")
+	if _, err := b.Build(); err != nil {
+		t.Fatal(err)
+	}
+
+	wantOrder := []string{
+		"MakeScalar.inner_r",
+		"MakeScalar.wire_width",
+		"Helix.1",
+		"ScalarMath.Helix.1.size-1",
+		"ScalarMath.Helix.1.size-2",
+		"ScalarMath.Helix.1.size-3",
+		"MakeVector.Helix.1.size",
+	}
+	if diff := cmp.Diff(wantOrder, b.NodeOrder); diff != "" {
+		t.Errorf("NodeOrder mismatch (-want +got):\n%v", diff)
+	}
+	want := []string{
+		"Helix.1.size <- MakeVector.Helix.1.size.v",
+		"ScalarMath.Helix.1.size-1.x <- MakeScalar.wire_width.x",
+		"ScalarMath.Helix.1.size-2.x <- MakeScalar.inner_r.x",
+		"ScalarMath.Helix.1.size-2.y <- ScalarMath.Helix.1.size-1.out",
+		"ScalarMath.Helix.1.size-3.y <- MakeScalar.inner_r.x",
+		"MakeVector.Helix.1.size.x <- ScalarMath.Helix.1.size-2.out",
+		"MakeVector.Helix.1.size.z <- ScalarMath.Helix.1.size-3.out",
+	}
+	if diff := cmp.Diff(want, connections(b)); diff != "" {
+		t.Errorf("connections mismatch (-want +got):\n%v", diff)
+	}
+	if diff := cmp.Diff(map[string]float64{"y": 6}, scalarValues(b, "MakeVector.Helix.1.size")); diff != "" {
+		t.Errorf("MakeVector values mismatch (-want +got):\n%v", diff)
+	}
+	if diff := cmp.Diff(map[string]float64{"x": 0}, scalarValues(b, "ScalarMath.Helix.1.size-3")); diff != "" {
+		t.Errorf("negation values mismatch (-want +got):\n%v", diff)
+	}
+}
+
+func TestParam_Group(t *testing.T) {
+	b := fakeParamBuilder().
+		NewGroup("Wire", func(b *Builder) *Builder {
+			return b.AddNode("Helix.h").
+				Input("segments", "Helix.h.segments").
+				Output("Helix.h.out_mesh", "out_mesh")
+		}).
+		AddNode("Wire.w", "segments=${wire_width}+${inner_r}")
+	if _, err := b.Build(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"Helix.Wire.w.h.segments <- ScalarMath.Helix.Wire.w.h.segments-1.out",
+		"ScalarMath.Helix.Wire.w.h.segments-1.x <- MakeScalar.wire_width.x",
+		"ScalarMath.Helix.Wire.w.h.segments-1.y <- MakeScalar.inner_r.x",
+	}
+	if diff := cmp.Diff(want, connections(b)); diff != "" {
+		t.Errorf("connections mismatch (-want +got):\n%v", diff)
+	}
+}
+
+func TestParam_Edit(t *testing.T) {
+	b := fakeParamBuilder().
+		AddNode("Helix.1", "segments=${wire_width}*6").
+		SetInput("Helix.1", "segments=${inner_r}+1")
+	want := []string{
+		"Helix.1.segments <- ScalarMath.Helix.1.segments-1.out",
+		"ScalarMath.Helix.1.segments-1.x <- MakeScalar.inner_r.x",
+	}
+	if diff := cmp.Diff(want, connections(b)); diff != "" {
+		t.Errorf("connections after SetInput expression mismatch (-want +got):\n%v", diff)
+	}
+
+	b = b.SetInput("Helix.1", Scalar("segments", 5))
+	if diff := cmp.Diff([]string{"MakeScalar.inner_r", "MakeScalar.wire_width", "Helix.1"}, b.NodeOrder); diff != "" {
+		t.Errorf("NodeOrder after SetInput value mismatch (-want +got):\n%v", diff)
+	}
+
+	b = b.SetInput("Helix.1", "size=vector(${inner_r},0,0)").RemoveNode("Helix.1")
+	if diff := cmp.Diff([]string{"MakeScalar.inner_r", "MakeScalar.wire_width"}, b.NodeOrder); diff != "" {
+		t.Errorf("NodeOrder after RemoveNode mismatch (-want +got):\n%v", diff)
+	}
+	if errs := b.Errors(); len(errs) > 0 {
+		t.Errorf("unexpected errors: %v", errs)
+	}
+}
+
+func TestParam_ReplaceAndDisconnect(t *testing.T) {
+	b := fakeParamBuilder().
+		AddNode("Helix.1", "segments=${wire_width}*2").
+		AddNode("Helix.2", "size=vector(${inner_r},0,1)").
+		AddNode("Helix.3", "segments=${inner_r}-1").
+		ReplaceNode("Helix.1", "Spiral").
+		ReplaceNode("Helix.2", "Spiral").
+		Disconnect("Helix.3.segments")
+	if errs := b.Errors(); len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+
+	// Spiral.1 kept the segments expression, Spiral.2 has no size input, and Helix.3 was disconnected.
+	want := []string{"MakeScalar.inner_r", "MakeScalar.wire_width", "Spiral.1", "ScalarMath.Spiral.1.segments-1", "Spiral.2", "Helix.3"}
+	if diff := cmp.Diff(want, b.NodeOrder); diff != "" {
+		t.Errorf("NodeOrder mismatch (-want +got):\n%v", diff)
+	}
+	wantConns := []string{
+		"Spiral.1.segments <- ScalarMath.Spiral.1.segments-1.out",
+		"ScalarMath.Spiral.1.segments-1.x <- MakeScalar.wire_width.x",
+	}
+	if diff := cmp.Diff(wantConns, connections(b)); diff != "" {
+		t.Errorf("connections mismatch (-want +got):\n%v", diff)
+	}
+
+	b = b.SetInput("Spiral.1", Scalar("segments", 5))
+	if diff := cmp.Diff([]string{"MakeScalar.inner_r", "MakeScalar.wire_width", "Spiral.1", "Spiral.2", "Helix.3"}, b.NodeOrder); diff != "" {
+		t.Errorf("NodeOrder after SetInput mismatch (-want +got):\n%v", diff)
+	}
+}
+
+func TestParam_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		fn      func(b *Builder) *Builder
+		wantErr string
+	}{
+		{
+			name:    "unknown parameter",
+			fn:      func(b *Builder) *Builder { return b.AddNode("Helix.1", "segments=${wire_widht}*2") },
+			wantErr: `Helix.1.segments: expression "${wire_widht}*2": unknown parameter ${wire_widht}; did you mean "wire_width"?`,
+		},
+		{
+			name:    "bad vector",
+			fn:      func(b *Builder) *Builder { return b.AddNode("Helix.1", "size=vector(${inner_r},1)") },
+			wantErr: "want 3 vector components, got 2",
+		},
+		{
+			name:    "division by zero",
+			fn:      func(b *Builder) *Builder { return b.AddNode("Helix.1", "segments=${inner_r}+1/0") },
+			wantErr: "division by zero",
+		},
+		{
+			name:    "duplicate parameter",
+			fn:      func(b *Builder) *Builder { return b.Param("inner_r", 4) },
+			wantErr: `Param("inner_r"): parameter already defined`,
+		},
+		{
+			name:    "bad parameter name",
+			fn:      func(b *Builder) *Builder { return b.Param("inner.r", 4) },
+			wantErr: "bad parameter name",
+		},
+		{
+			name: "within a group",
+			fn: func(b *Builder) *Builder {
+				return b.NewGroup("G", func(b *Builder) *Builder { return b.Param("x", 1) }).Groups["G"]
+			},
+			wantErr: "cannot be used within a group",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := tt.fn(fakeParamBuilder())
+			if errs := b.Errors(); len(errs) != 1 || !strings.Contains(errs[0].Error(), tt.wantErr) {
+				t.Errorf("errs = %v, want %v", errs, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package nodes
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gmlewis/go-bjk/ast"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// fakeLibrary returns a small node library modeled after the Blackjack nodes
+// of the same names so that the Builder can be tested without a Blackjack
+// repo (which the Client `c` of TestMain requires). Tests that need the real
+// node library use `c` instead.
+func fakeLibrary() map[string]*ast.Node {
+	helixSegments := scalarInput("segments", 36)
+	helixSegments.Props["min"] = lua.LNumber(3)
+
+	return map[string]*ast.Node{
+		"Helix": {
+			OpName: "Helix",
+			Inputs: []*ast.Input{
+				scalarInput("start_angle", 0),
+				helixSegments,
+				enumInput("direction", 1, "Clockwise", "Counter-Clockwise"),
+				{
+					Name:     "size",
+					DataType: "vec3",
+					Props:    map[string]lua.LValue{"type": lua.LString("vec3"), "default": &lua.LUserData{Value: &Vec3{X: 1, Y: 1, Z: 1}}},
+				},
+			},
+			Outputs: []*ast.Output{{Name: "out_mesh", DataType: "mesh"}},
+		},
+		"MakeScalar": {
+			OpName:  "MakeScalar",
+			Inputs:  []*ast.Input{scalarInput("x", 0)},
+			Outputs: []*ast.Output{{Name: "x", DataType: "scalar"}},
+		},
+		"MakeVector": {
+			OpName:  "MakeVector",
+			Inputs:  []*ast.Input{scalarInput("x", 0), scalarInput("y", 0), scalarInput("z", 0)},
+			Outputs: []*ast.Output{{Name: "v", DataType: "vec3"}},
+		},
+		"MergeMeshes": {
+			OpName: "MergeMeshes",
+			Inputs: []*ast.Input{
+				{Name: "mesh_a", DataType: "mesh", Props: map[string]lua.LValue{"type": lua.LString("mesh")}},
+				{Name: "mesh_b", DataType: "mesh", Props: map[string]lua.LValue{"type": lua.LString("mesh")}},
+			},
+			Outputs: []*ast.Output{{Name: "out_mesh", DataType: "mesh"}},
+		},
+		"ScalarMath": {
+			OpName: "ScalarMath",
+			Inputs: []*ast.Input{
+				enumInput("op", 0, "Add", "Sub", "Mul", "Div"),
+				scalarInput("x", 0),
+				scalarInput("y", 0),
+			},
+			Outputs: []*ast.Output{{Name: "out", DataType: "scalar"}},
+		},
+		"Spiral": {
+			OpName:  "Spiral",
+			Inputs:  []*ast.Input{scalarInput("segments", 10), scalarInput("radius", 1)},
+			Outputs: []*ast.Output{{Name: "out_mesh", DataType: "mesh"}},
+		},
+	}
+}
+
+// fakeBuilder returns a new Builder for the nodes of fakeLibrary.
+func fakeBuilder() *Builder {
+	return (&Client{Nodes: fakeLibrary()}).NewBuilder()
+}
+
+// fakeDesign returns a fakeBuilder whose Helix.a and Helix.b are merged by
+// MergeMeshes.m, which is in turn connected to MergeMeshes.n.
+func fakeDesign(t *testing.T) *Builder {
+	t.Helper()
+	b := fakeBuilder().
+		AddNode("Helix.a", Scalar("segments", 24)).
+		AddNode("Helix.b").
+		AddNode("MergeMeshes.m").
+		AddNode("MergeMeshes.n").
+		Connect("Helix.a.out_mesh", "MergeMeshes.m.mesh_a").
+		Connect("Helix.b.out_mesh", "MergeMeshes.m.mesh_b").
+		Connect("MergeMeshes.m.out_mesh", "MergeMeshes.n.mesh_a")
+	if len(b.errs) > 0 {
+		t.Fatalf("unexpected errors: %v", b.errs)
+	}
+	return b
+}
+
+// fakeParamBuilder returns a fakeBuilder with the inner_r and wire_width params.
+func fakeParamBuilder() *Builder {
+	return fakeBuilder().
+		Param("inner_r", 3).
+		Param("wire_width", 1)
+}
+
+// fakeWireBuilder defines a Wire group made of a Helix whose segments
+// input has a default, whose angle input is optional, and whose size input is typed.
+func fakeWireBuilder(t *testing.T) *Builder {
+	t.Helper()
+	return fakeBuilder().
+		NewGroup("Wire", func(b *Builder) *Builder {
+			return b.AddNode("Helix.h").
+				Input("segments", "Helix.h.segments", InputDefault(24)).
+				Input("angle", "Helix.h.start_angle", InputOptional()).
+				Input("size", "Helix.h.size", InputType("vec3")).
+				Output("Helix.h.out_mesh", "out_mesh")
+		})
+}
+
+// fakeCoilPairBuilder defines a CoilPair group made of two Coil groups, each
+// made of two Wire groups. Wire and Coil are only defined within CoilPair.
+func fakeCoilPairBuilder(t *testing.T) *Builder {
+	t.Helper()
+	return fakeBuilder().
+		NewGroup("CoilPair", func(b *Builder) *Builder {
+			return b.
+				NewGroup("Wire", func(b *Builder) *Builder {
+					return b.AddNode("Helix.h").
+						Input("segments", "Helix.h.segments").
+						Input("angle", "Helix.h.start_angle").
+						Output("Helix.h.out_mesh", "out_mesh")
+				}).
+				NewGroup("Coil", func(b *Builder) *Builder {
+					return b.AddNode("Wire.inner").
+						AddNode("Wire.outer").
+						AddNode("MergeMeshes.coil").
+						Connect("Wire.inner.out_mesh", "MergeMeshes.coil.mesh_a").
+						Connect("Wire.outer.out_mesh", "MergeMeshes.coil.mesh_b").
+						Input("segments", "Wire.inner.segments").
+						Input("segments", "Wire.outer.segments").
+						Input("angle", "Wire.inner.angle").
+						Input("angle", "Wire.outer.angle").
+						Output("MergeMeshes.coil.out_mesh", "out_mesh")
+				}).
+				AddNode("Coil.left").
+				AddNode("Coil.right").
+				AddNode("MergeMeshes.pair").
+				Connect("Coil.left.out_mesh", "MergeMeshes.pair.mesh_a").
+				Connect("Coil.right.out_mesh", "MergeMeshes.pair.mesh_b").
+				Input("segments", "Coil.left.segments").
+				Input("segments", "Coil.right.segments").
+				Input("angle", "Coil.left.angle").
+				Input("angle", "Coil.right.angle").
+				Output("MergeMeshes.pair.out_mesh", "out_mesh")
+		}).
+		AddNode("MakeScalar.angle").
+		AddNode("CoilPair.cp", Scalar("segments", 12)).
+		AddNode("MergeMeshes.top")
+}
+
+// connections lists each connected input of the builder as "node.input <- node.output".
+func connections(b *Builder) []string {
+	var result []string
+	for _, name := range b.NodeOrder {
+		for _, input := range b.Nodes[name].Inputs {
+			if conn := input.Kind.Connection; conn != nil {
+				result = append(result, fmt.Sprintf("%v.%v <- %v.%v", name, input.Name, b.NodeOrder[conn.NodeIdx], conn.ParamName))
+			}
+		}
+	}
+	return result
+}
+
+// scalarValues returns the values of the scalar inputs of the named node.
+func scalarValues(b *Builder, name string) map[string]float64 {
+	result := map[string]float64{}
+	for _, input := range b.Nodes[name].Inputs {
+		if x, ok := input.Props["default"].(lua.LNumber); ok && input.Kind.Connection == nil {
+			result[input.Name] = float64(x)
+		}
+	}
+	return result
+}
+
+func scalarInput(name string, def float64) *ast.Input {
+	return &ast.Input{
+		Name:     name,
+		DataType: "scalar",
+		Props:    map[string]lua.LValue{"type": lua.LString("scalar"), "default": lua.LNumber(def)},
+	}
+}
+
+func enumInput(name string, selected int, values ...string) *ast.Input {
+	t := &lua.LTable{}
+	for _, v := range values {
+		t.Append(lua.LString(v))
+	}
+	return &ast.Input{
+		Name:     name,
+		DataType: "enum",
+		Props:    map[string]lua.LValue{"type": lua.LString("enum"), "values": t, "selected": lua.LNumber(selected)},
+	}
+}
@@ -0,0 +1,186 @@
+package nodes
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gmlewis/go-bjk/ast"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// NewBuilderFromBJK returns a new Builder that contains the nodes,
+// connections, and parameter values of a parsed design so that it
+// can be edited further and built again.
+//
+// Each node keeps the name saved in the design (see ast.Node.Name) or, if
+// it has none, gets a name synthesized from its op name and index in the
+// same way as AddNode labels unlabeled nodes (e.g. "Helix.node-3" for the
+// node with index 2); a saved name without a label (e.g. "Helix") gets a
+// label in the same way. Node positions, the default node, and the rest of
+// the design's UI data (pan, zoom, node order, and locked gizmo nodes) are
+// preserved.
+// Designs that do not match the node library should first be updated
+// with Migrate.
+func (c *Client) NewBuilderFromBJK(design *ast.BJK) (*Builder, error) {
+	if design == nil || design.Graph == nil {
+		return nil, errors.New("NewBuilderFromBJK: design missing graph")
+	}
+	g := design.Graph
+
+	b := c.NewBuilder()
+	names := make([]string, len(g.Nodes))
+	for i, n := range g.Nodes {
+		lib, ok := c.Nodes[n.OpName]
+		if !ok {
+			return nil, fmt.Errorf("NewBuilderFromBJK: node %v: unknown node type '%v' (see bjk-migrate)", i, n.OpName)
+		}
+
+		name := n.Name
+		if name == "" {
+			name = fmt.Sprintf("%v.node-%v", n.OpName, i+1)
+		}
+		if _, ok := b.Nodes[name]; ok {
+			return nil, fmt.Errorf("NewBuilderFromBJK: node %v: duplicate node name '%v'", i, name)
+		}
+
+		b = b.instantiateNode(n.OpName, name, lib, nil)
+		if len(b.errs) > 0 {
+			return nil, fmt.Errorf("NewBuilderFromBJK: node %v: %w", i, errors.Join(b.errs...))
+		}
+		// instantiateNode labels a name without one (e.g. "Helix" becomes "Helix.node-3").
+		name = b.NodeOrder[len(b.NodeOrder)-1]
+		names[i] = name
+		node := b.Nodes[name]
+		if g.UIData != nil && i < len(g.UIData.NodePositions) {
+			node.NodePosition = g.UIData.NodePositions[i]
+		}
+
+		for _, in := range n.Inputs {
+			input, ok := node.GetInput(in.Name)
+			if !ok {
+				return nil, fmt.Errorf("NewBuilderFromBJK: node %v (%v): unknown input %q (see bjk-migrate)", i, n.OpName, in.Name)
+			}
+			if ext := in.Kind.External; ext != nil && input.Kind.External != nil {
+				input.Kind.External.Promoted = ext.Promoted
+			}
+		}
+	}
+
+	if g.ExternalParameters != nil {
+		for _, pv := range g.ExternalParameters.ParamValues {
+			if pv.NodeIdx >= uint64(len(names)) {
+				return nil, fmt.Errorf("NewBuilderFromBJK: param value %q: bad node index %v", pv.ParamName, pv.NodeIdx)
+			}
+			name := names[pv.NodeIdx]
+			input, ok := b.Nodes[name].GetInput(pv.ParamName)
+			if !ok {
+				return nil, fmt.Errorf("NewBuilderFromBJK: node %v (%v): unknown input %q (see bjk-migrate)", pv.NodeIdx, g.Nodes[pv.NodeIdx].OpName, pv.ParamName)
+			}
+			if err := setInputValueEnum(input, &pv.ValueEnum); err != nil {
+				return nil, fmt.Errorf("NewBuilderFromBJK: node %v (%v): %w", pv.NodeIdx, g.Nodes[pv.NodeIdx].OpName, err)
+			}
+			b.InputsAlreadyConnected[fmt.Sprintf("%v.%v", name, pv.ParamName)] = argValue(&pv.ValueEnum)
+		}
+	}
+
+	for i, n := range g.Nodes {
+		for _, in := range n.Inputs {
+			conn := in.Kind.Connection
+			if conn == nil {
+				continue
+			}
+			if conn.NodeIdx >= uint64(len(names)) {
+				return nil, fmt.Errorf("NewBuilderFromBJK: node %v (%v) input %q: bad node index %v", i, n.OpName, in.Name, conn.NodeIdx)
+			}
+			b = b.Connect(PortRef{Node: names[conn.NodeIdx], Port: conn.ParamName}, PortRef{Node: names[i], Port: in.Name})
+		}
+	}
+	if len(b.errs) > 0 {
+		return nil, fmt.Errorf("NewBuilderFromBJK: %w", errors.Join(b.errs...))
+	}
+
+	if g.DefaultNode != nil && *g.DefaultNode < uint64(len(names)) {
+		b.DefaultNode = names[*g.DefaultNode]
+	}
+
+	if ui := g.UIData; ui != nil {
+		b.uiState = &uiState{pan: ui.Pan, zoom: ui.Zoom}
+		for _, idx := range ui.NodeOrder {
+			if idx < uint64(len(names)) {
+				b.uiState.nodeOrder = append(b.uiState.nodeOrder, names[idx])
+			}
+		}
+		for _, idx := range ui.LockedGizmoNodes {
+			if idx < uint64(len(names)) {
+				b.uiState.lockedGizmoNodes = append(b.uiState.lockedGizmoNodes, names[idx])
+			}
+		}
+	}
+
+	return b, nil
+}
+
+// setInputValueEnum sets the value of a Builder node's input from a design's parameter value.
+func setInputValueEnum(input *ast.Input, ve *ast.ValueEnum) error {
+	t, _ := input.Props["type"].(lua.LString)
+	switch {
+	case ve.Scalar != nil && t == "scalar":
+		return setInputScalar(t, input, ve.Scalar.X)
+	case ve.Vector != nil && t == "vec3":
+		return setInputVector(t, input, Vec3{X: ve.Vector.X, Y: ve.Vector.Y, Z: ve.Vector.Z})
+	case ve.StrVal != nil && t == "enum":
+		return setInputEnumValue(t, input, ve.StrVal.S)
+	case ve.StrVal != nil && t == "string":
+		return setInputStringValue(t, input, ve.StrVal.S)
+	case ve.StrVal != nil && t == "lua_string":
+		return setInputSelectionValue(t, input, ve.StrVal.S)
+	case ve.Selection != nil && t == "selection":
+		return setInputSelectionValue(t, input, ve.Selection.Selection)
+	}
+	return fmt.Errorf("input %q of type %q cannot be set to %v", input.Name, t, ve)
+}
+
+// argValue returns the parameter value as it would be written in an AddNode arg.
+func argValue(ve *ast.ValueEnum) string {
+	switch {
+	case ve.Scalar != nil:
+		return Scalar("", ve.Scalar.X).value()
+	case ve.Vector != nil:
+		return Vector("", Vec3{X: ve.Vector.X, Y: ve.Vector.Y, Z: ve.Vector.Z}).value()
+	case ve.StrVal != nil:
+		return ve.StrVal.S
+	case ve.Selection != nil:
+		return ve.Selection.Selection
+	}
+	return ""
+}
+
+// uiState is the view state of a loaded design, which refers to nodes by
+// name since their indices change as the design is edited.
+type uiState struct {
+	pan              ast.Vec2
+	zoom             float64
+	nodeOrder        []string
+	lockedGizmoNodes []string
+}
+
+// nodeIndices returns the indices of the named nodes that are still in the
+// design, followed (if addMissing) by the indices of all other nodes.
+func (b *Builder) nodeIndices(names []string, addMissing bool) []uint64 {
+	result := []uint64{}
+	seen := map[string]bool{}
+	for _, name := range names {
+		if node, ok := b.Nodes[name]; ok && !seen[name] {
+			seen[name] = true
+			result = append(result, node.Index)
+		}
+	}
+	if addMissing {
+		for i, name := range b.NodeOrder {
+			if !seen[name] {
+				result = append(result, uint64(i))
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,212 @@
+package nodes
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gmlewis/go-bjk/ast"
+	"github.com/google/go-cmp/cmp"
+)
+
+func fromBJKDesign(t *testing.T, c *Client) *ast.BJK {
+	t.Helper()
+	design, err := c.NewBuilder().
+		AddNode("Helix.a", Scalar("segments", 24), Enum("direction", "Clockwise"), Vector("size", Vec3{X: 2, Y: 3, Z: 4})).
+		AddNode("Helix.b", Scalar("start_angle", 180)).
+		AddNode("MergeMeshes.m").
+		Connect("Helix.a.out_mesh", "MergeMeshes.m.mesh_a").
+		Connect("Helix.b.out_mesh", "MergeMeshes.m.mesh_b").
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Parse the design to strip everything that is not saved in a BJK file.
+	parsed, err := ast.ParseString("design.bjk", design.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed
+}
+
+func TestNewBuilderFromBJK_RoundTrip(t *testing.T) {
+	c := &Client{Nodes: fakeLibrary()}
+	design := fromBJKDesign(t, c)
+
+	b, err := c.NewBuilderFromBJK(design)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"Helix.a", "Helix.b", "MergeMeshes.m"}, b.NodeOrder); diff != "" {
+		t.Errorf("NodeOrder mismatch (-want +got):\n%v", diff)
+	}
+
+	got, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(design.String(), got.String()); diff != "" {
+		t.Errorf("rebuilt design mismatch (-want +got):\n%v", diff)
+	}
+}
+
+func TestNewBuilderFromBJK_Edit(t *testing.T) {
+	c := &Client{Nodes: fakeLibrary()}
+	design := fromBJKDesign(t, c)
+	for _, n := range design.Graph.Nodes {
+		n.Name = ""
+	}
+	dn := uint64(0)
+	design.Graph.DefaultNode = &dn
+
+	b, err := c.NewBuilderFromBJK(design)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"Helix.node-1", "Helix.node-2", "MergeMeshes.node-3"}, b.NodeOrder); diff != "" {
+		t.Errorf("NodeOrder mismatch (-want +got):\n%v", diff)
+	}
+
+	wantConnected := map[string]string{
+		"Helix.node-1.segments":     "24",
+		"Helix.node-1.direction":    "Clockwise",
+		"Helix.node-1.size":         "vector(2,3,4)",
+		"MergeMeshes.node-3.mesh_a": "Helix.node-1.out_mesh",
+		"MergeMeshes.node-3.mesh_b": "Helix.node-2.out_mesh",
+	}
+	for k, want := range wantConnected {
+		if got := b.InputsAlreadyConnected[k]; got != want {
+			t.Errorf("InputsAlreadyConnected[%q] = %q, want %q", k, got, want)
+		}
+	}
+
+	// Already-connected inputs are still enforced, and new nodes can be added.
+	b.AddNode("Helix", "segments=12").
+		Connect("Helix.node-4.out_mesh", "MergeMeshes.node-3.mesh_b")
+	if len(b.errs) != 1 {
+		t.Fatalf("got %v errors, want 1: %v", len(b.errs), b.errs)
+	}
+
+	b.errs = nil
+	got, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := *got.Graph.DefaultNode, uint64(0); got != want {
+		t.Errorf("DefaultNode = %v, want %v", got, want)
+	}
+	if got, want := len(got.Graph.Nodes), 4; got != want {
+		t.Errorf("got %v nodes, want %v", got, want)
+	}
+}
+
+func TestNewBuilderFromBJK_Errors(t *testing.T) {
+	c := &Client{Nodes: fakeLibrary()}
+
+	design := fromBJKDesign(t, c)
+	design.Graph.Nodes[2].OpName = "Cube"
+	if _, err := c.NewBuilderFromBJK(design); err == nil {
+		t.Error("unknown node type: want error")
+	}
+
+	design = fromBJKDesign(t, c)
+	design.Graph.Nodes[0].Inputs[0].Name = "angle"
+	if _, err := c.NewBuilderFromBJK(design); err == nil {
+		t.Error("unknown input: want error")
+	}
+}
+
+func TestNewBuilderFromBJK_UnlabeledName(t *testing.T) {
+	c := &Client{Nodes: fakeLibrary()}
+	design := fromBJKDesign(t, c)
+	design.Graph.Nodes[1].Name = "Helix"
+
+	b, err := c.NewBuilderFromBJK(design)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"Helix.a", "Helix.node-2", "MergeMeshes.m"}, b.NodeOrder); diff != "" {
+		t.Errorf("NodeOrder mismatch (-want +got):\n%v", diff)
+	}
+	if got, want := b.InputsAlreadyConnected["MergeMeshes.m.mesh_b"], "Helix.node-2.out_mesh"; got != want {
+		t.Errorf("mesh_b connected to %q, want %q", got, want)
+	}
+	if got, want := b.InputsAlreadyConnected["Helix.node-2.start_angle"], "180"; got != want {
+		t.Errorf("start_angle = %q, want %q", got, want)
+	}
+}
+
+func TestNewBuilderFromBJK_UIData(t *testing.T) {
+	c := &Client{Nodes: fakeLibrary()}
+	design := fromBJKDesign(t, c)
+	ui := design.Graph.UIData
+	ui.Pan = ast.Vec2{X: 12.5, Y: -3}
+	ui.Zoom = 1.5
+	ui.NodeOrder = []uint64{2, 0, 1}
+	ui.LockedGizmoNodes = []uint64{1}
+
+	b, err := c.NewBuilderFromBJK(design)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(design.String(), got.String()); diff != "" {
+		t.Errorf("rebuilt design mismatch (-want +got):\n%v", diff)
+	}
+
+	// Edits keep the UI data of the remaining nodes.
+	got, err = b.RemoveNode("Helix.a").AddNode("Helix.c").Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &ast.UIData{
+		NodePositions:    got.Graph.UIData.NodePositions,
+		NodeOrder:        []uint64{1, 0, 2},
+		Pan:              ast.Vec2{X: 12.5, Y: -3},
+		Zoom:             1.5,
+		LockedGizmoNodes: []uint64{0},
+	}
+	if diff := cmp.Diff(want, got.Graph.UIData); diff != "" {
+		t.Errorf("UIData mismatch (-want +got):\n%v", diff)
+	}
+}
+
+func TestNewBuilderFromBJK_Blackjack(t *testing.T) {
+	t.Parallel()
+	if c == nil {
+		t.Fatalf("c is nil")
+	}
+	design, err := ast.ParseString("bifilar-electromagnet.bjk", bifilarElectromagnet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := c.NewBuilderFromBJK(design)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// graphSummary lists the op names and connections of a design's nodes.
+	graphSummary := func(design *ast.BJK) []string {
+		var result []string
+		for i, n := range design.Graph.Nodes {
+			result = append(result, fmt.Sprintf("%v: %v", i, n.OpName))
+			for _, input := range n.Inputs {
+				if conn := input.Kind.Connection; conn != nil {
+					result = append(result, fmt.Sprintf("%v.%v <- %v.%v", i, input.Name, conn.NodeIdx, conn.ParamName))
+				}
+			}
+		}
+		return result
+	}
+	if diff := cmp.Diff(graphSummary(design), graphSummary(got)); diff != "" {
+		t.Errorf("rebuilt graph mismatch (-want +got):\n%v", diff)
+	}
+}
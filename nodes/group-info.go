@@ -0,0 +1,163 @@
+package nodes
+
+import (
+	"fmt"
+	"sort"
+
+	"golang.org/x/exp/maps"
+)
+
+// GroupInfo describes a group defined with NewGroup.
+type GroupInfo struct {
+	// Name is the name of the group.
+	Name string
+	// Inputs are the group's inputs in the order they were first declared.
+	Inputs []GroupPort
+	// Outputs are the group's outputs in the order they were declared.
+	Outputs []GroupPort
+	// Groups are the sorted names of the groups defined within the group.
+	Groups []string
+}
+
+// GroupPort describes an input or output of a group.
+type GroupPort struct {
+	// Name is the name of the port.
+	Name string
+	// DataType is the declared data type of the port or, if none was
+	// declared, the data type of its internal port (e.g. "scalar" or "mesh").
+	DataType string
+	// Optional reports whether an input may be left unconnected and unassigned.
+	Optional bool
+	// Default is the default value of an input (e.g. "segments=36"), or nil if it has none.
+	Default *Arg
+	// Ports are the internal ports that an input is connected to
+	// or that an output is connected from.
+	Ports []string
+}
+
+// GroupNames returns the sorted names of the groups defined within the builder.
+func (b *Builder) GroupNames() []string {
+	names := maps.Keys(b.Groups)
+	sort.Strings(names)
+	return names
+}
+
+// GroupInfo describes the named group, which is defined within the builder
+// or (for a group builder) within one of its enclosing builders.
+// The groups defined within a group can be described with
+// b.Groups[name].GroupInfo(innerName).
+func (b *Builder) GroupInfo(name string) (*GroupInfo, error) {
+	g, ok := b.lookupGroup(name)
+	if !ok {
+		return nil, &UnknownNodeTypeError{Op: fmt.Sprintf("GroupInfo(%q)", name), Node: name, Type: name, Suggestions: closeMatches(name, maps.Keys(b.Groups))}
+	}
+	inputs, outputs := g.groupPorts()
+	return &GroupInfo{
+		Name:    name,
+		Inputs:  inputs,
+		Outputs: outputs,
+		Groups:  g.GroupNames(),
+	}, nil
+}
+
+// groupPorts returns the inputs and outputs declared within group g.
+func (g *Builder) groupPorts() (inputs, outputs []GroupPort) {
+	inputIdx := map[string]int{}
+	var inputRefs, outputRefs []PortRef // the first internal port of each input and output
+	for _, step := range g.groupRecorder {
+		switch step.action {
+		case "Input":
+			name := step.port
+			i, ok := inputIdx[name]
+			if !ok {
+				i = len(inputs)
+				inputIdx[name] = i
+				inputs = append(inputs, GroupPort{Name: name})
+				inputRefs = append(inputRefs, step.to)
+			}
+			in := &inputs[i]
+			in.Ports = append(in.Ports, step.to.String())
+			if opts := step.input; opts != nil {
+				if opts.dataType != "" {
+					in.DataType = opts.dataType
+				}
+				in.Optional = in.Optional || opts.optional
+				if opts.def != nil {
+					def := *opts.def
+					def.Name = name
+					in.Default = &def
+				}
+			}
+		case "Output":
+			outputs = append(outputs, GroupPort{Name: step.port, Ports: []string{step.from.String()}})
+			outputRefs = append(outputRefs, step.from)
+		}
+	}
+
+	for i, in := range inputs {
+		if in.DataType == "" {
+			inputs[i].DataType = g.portDataType(inputRefs[i], false)
+		}
+	}
+	for i := range outputs {
+		outputs[i].DataType = g.portDataType(outputRefs[i], true)
+	}
+
+	return inputs, outputs
+}
+
+// portDataType returns the data type of an input (or output) port of a node
+// or group instance within group g, or "" if it is unknown.
+func (g *Builder) portDataType(ref PortRef, output bool) string {
+	t := nodeType(ref.Node)
+	if n, ok := g.c.Nodes[t]; ok {
+		if output {
+			if out, ok := n.GetOutput(ref.Port); ok {
+				return out.DataType
+			}
+			return ""
+		}
+		if in, ok := n.GetInput(ref.Port); ok {
+			return in.DataType
+		}
+		return ""
+	}
+
+	def, ok := g.lookupGroup(t)
+	if !ok {
+		return ""
+	}
+	inputs, outputs := def.groupPorts()
+	ports := inputs
+	if output {
+		ports = outputs
+	}
+	for _, p := range ports {
+		if p.Name == ref.Port {
+			return p.DataType
+		}
+	}
+	return ""
+}
+
+// groupInputTargets returns the full names of the node inputs that the
+// named input of a group instance is connected to, following nested groups.
+func (b *Builder) groupInputTargets(instanceName, inputName string) []string {
+	g, ok := b.groupOf(instanceName)
+	if !ok {
+		return nil
+	}
+	var result []string
+	for _, step := range g.groupRecorder {
+		if step.action != "Input" || step.port != inputName {
+			continue
+		}
+		to := injectGroupRef(step.to, instanceName)
+		if _, ok := b.groupInstances[to.Node]; ok {
+			result = append(result, b.groupInputTargets(to.Node, to.Port)...)
+			continue
+		}
+		result = append(result, to.String())
+	}
+	return result
+}
@@ -0,0 +1,341 @@
+package nodes
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// groupLibraryVersion is the version of the group library file format
+// written by SaveGroup.
+const groupLibraryVersion = 1
+
+// A group library file is a JSON encoding of the recorded steps of one or
+// more groups. For example:
+//
+//	{
+//	  "version": 1,
+//	  "groups": [
+//	    {
+//	      "name": "Wire",
+//	      "steps": [
+//	        {"action": "AddNode", "node": "Helix.h", "args": [{"name": "direction", "enum": "Clockwise"}]},
+//	        {"action": "Input", "input": "segments", "to": "Helix.h.segments", "type": "scalar", "optional": true, "default": {"scalar": 24}},
+//	        {"action": "Output", "from": "Helix.h.out_mesh", "output": "out_mesh"}
+//	      ]
+//	    }
+//	  ]
+//	}
+
+type jsonGroupLibrary struct {
+	Version int          `json:"version"`
+	Groups  []*jsonGroup `json:"groups"`
+}
+
+type jsonGroup struct {
+	Name   string       `json:"name"`
+	Groups []*jsonGroup `json:"groups,omitempty"`
+	Steps  []*jsonStep  `json:"steps"`
+}
+
+type jsonStep struct {
+	Action   string     `json:"action"`
+	Node     string     `json:"node,omitempty"`
+	Args     []*jsonArg `json:"args,omitempty"`
+	Input    string     `json:"input,omitempty"`
+	From     string     `json:"from,omitempty"`
+	To       string     `json:"to,omitempty"`
+	Output   string     `json:"output,omitempty"`
+	Type     string     `json:"type,omitempty"`
+	Optional bool       `json:"optional,omitempty"`
+	Default  *jsonArg   `json:"default,omitempty"`
+}
+
+type jsonArg struct {
+	Name   string      `json:"name,omitempty"`
+	Scalar *float64    `json:"scalar,omitempty"`
+	Vector *[3]float64 `json:"vector,omitempty"`
+	Enum   *string     `json:"enum,omitempty"`
+	Str    *string     `json:"str,omitempty"`
+	Value  *string     `json:"value,omitempty"` // the value of a legacy "name=value" arg
+}
+
+// LoadGroups defines the groups of the group library file at path (written
+// by SaveGroup) so that they can be instantiated with AddNode just like
+// groups defined with NewGroup. A group that is already defined is an
+// error unless it is identical to the one in the file. If any group fails
+// to load, none of the groups in the file are defined.
+func (b *Builder) LoadGroups(path string) *Builder {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		b.errs = append(b.errs, fmt.Errorf("LoadGroups(%q): %w", path, err))
+		return b
+	}
+	var lib jsonGroupLibrary
+	if err := json.Unmarshal(buf, &lib); err != nil {
+		b.errs = append(b.errs, fmt.Errorf("LoadGroups(%q): %w", path, err))
+		return b
+	}
+	if lib.Version < 1 || lib.Version > groupLibraryVersion {
+		b.errs = append(b.errs, fmt.Errorf("LoadGroups(%q): unsupported group library version %v", path, lib.Version))
+		return b
+	}
+
+	// Define the groups within a staging builder first so that later groups
+	// can use earlier ones while b is left unchanged if any of them fails.
+	staged := b.c.NewBuilder()
+	staged.isGroup = true
+	staged.parent = b
+	for _, jg := range lib.Groups {
+		if g, ok := b.Groups[jg.Name]; ok {
+			if sameGroup(g, jg) {
+				continue
+			}
+			b.errs = append(b.errs, fmt.Errorf("LoadGroups(%q): already defined group %q", path, jg.Name))
+			return b
+		}
+		if err := staged.defineGroup(jg); err != nil {
+			b.errs = append(b.errs, fmt.Errorf("LoadGroups(%q): %w", path, err))
+			return b
+		}
+	}
+
+	for name, g := range staged.Groups {
+		g.parent = b
+		b.Groups[name] = g
+	}
+	return b
+}
+
+// SaveGroup writes the named group (including the groups defined within it)
+// to a group library file at path. Groups that it uses but that are defined
+// outside of it are written too, so that the file can be loaded on its own.
+func (b *Builder) SaveGroup(name, path string) error {
+	g, ok := b.lookupGroup(name)
+	if !ok {
+		return &UnknownNodeTypeError{Op: fmt.Sprintf("SaveGroup(%q)", name), Node: name, Type: name, Suggestions: closeMatches(name, b.GroupNames())}
+	}
+
+	lib := &jsonGroupLibrary{Version: groupLibraryVersion}
+	var names []string
+	deps := map[string]*Builder{}
+	g.externalGroups(g, deps, &names)
+	for _, depName := range names {
+		lib.Groups = append(lib.Groups, deps[depName].toJSONGroup(depName))
+	}
+	lib.Groups = append(lib.Groups, g.toJSONGroup(name))
+
+	buf, err := json.MarshalIndent(lib, "", "  ")
+	if err != nil {
+		return fmt.Errorf("SaveGroup(%q): %w", name, err)
+	}
+	if err := os.WriteFile(path, append(buf, '\n'), 0644); err != nil {
+		return fmt.Errorf("SaveGroup(%q): %w", name, err)
+	}
+	return nil
+}
+
+// externalGroups finds the groups used by group g (or the groups defined
+// within it) that are not defined within root, recording the names of new
+// ones in *order, dependencies first.
+func (g *Builder) externalGroups(root *Builder, deps map[string]*Builder, order *[]string) {
+	for _, innerName := range g.GroupNames() {
+		g.Groups[innerName].externalGroups(root, deps, order)
+	}
+	for _, step := range g.groupRecorder {
+		if step.action != "AddNode" {
+			continue
+		}
+		t := nodeType(step.node)
+		if _, ok := g.c.Nodes[t]; ok {
+			continue
+		}
+		def, ok := g.lookupGroup(t)
+		if !ok || deps[t] != nil || isWithin(def, root) {
+			continue
+		}
+		deps[t] = def
+		def.externalGroups(def, deps, order)
+		*order = append(*order, t)
+	}
+}
+
+// isWithin reports whether group g is root or is defined within it.
+func isWithin(g, root *Builder) bool {
+	for s := g; s != nil; s = s.parent {
+		if s == root {
+			return true
+		}
+	}
+	return false
+}
+
+// toJSONGroup returns the file representation of group g.
+func (g *Builder) toJSONGroup(name string) *jsonGroup {
+	jg := &jsonGroup{Name: name, Steps: []*jsonStep{}}
+	for _, innerName := range g.GroupNames() {
+		jg.Groups = append(jg.Groups, g.Groups[innerName].toJSONGroup(innerName))
+	}
+
+	for _, step := range g.groupRecorder {
+		js := &jsonStep{Action: step.action}
+		switch step.action {
+		case "AddNode":
+			js.Node = step.node
+			for _, arg := range step.nodeArgs {
+				js.Args = append(js.Args, toJSONArg(arg))
+			}
+		case "Connect":
+			js.From, js.To = step.from.String(), step.to.String()
+		case "Input":
+			js.Input, js.To = step.port, step.to.String()
+			if opts := step.input; opts != nil {
+				js.Type = opts.dataType
+				js.Optional = opts.optional
+				if opts.def != nil {
+					js.Default = toJSONArg(*opts.def)
+				}
+			}
+		case "Output":
+			js.From, js.Output = step.from.String(), step.port
+		}
+		jg.Steps = append(jg.Steps, js)
+	}
+	return jg
+}
+
+func toJSONArg(arg Arg) *jsonArg {
+	ja := &jsonArg{Name: arg.Name}
+	switch arg.kind {
+	case argScalar:
+		x := arg.x
+		ja.Scalar = &x
+	case argVector:
+		ja.Vector = &[3]float64{arg.v.X, arg.v.Y, arg.v.Z}
+	case argEnum:
+		s := arg.s
+		ja.Enum = &s
+	case argStr:
+		s := arg.s
+		ja.Str = &s
+	default:
+		s := arg.s
+		ja.Value = &s
+	}
+	return ja
+}
+
+// toArg returns the Arg of the file representation of an arg.
+func (ja *jsonArg) toArg() (Arg, error) {
+	var args []Arg
+	if ja.Scalar != nil {
+		args = append(args, Scalar(ja.Name, *ja.Scalar))
+	}
+	if v := ja.Vector; v != nil {
+		args = append(args, Vector(ja.Name, Vec3{X: v[0], Y: v[1], Z: v[2]}))
+	}
+	if ja.Enum != nil {
+		args = append(args, Enum(ja.Name, *ja.Enum))
+	}
+	if ja.Str != nil {
+		args = append(args, Str(ja.Name, *ja.Str))
+	}
+	if ja.Value != nil {
+		args = append(args, Arg{Name: ja.Name, kind: argString, s: *ja.Value})
+	}
+	if len(args) != 1 {
+		return Arg{}, fmt.Errorf("arg %q must have exactly one of: scalar, vector, enum, str, value", ja.Name)
+	}
+	return args[0], nil
+}
+
+// defineGroup defines the group of a group library file within b
+// by replaying its recorded steps.
+func (b *Builder) defineGroup(jg *jsonGroup) error {
+	if jg.Name == "" || strings.Contains(jg.Name, ".") {
+		return fmt.Errorf("bad group name %q", jg.Name)
+	}
+	if g, ok := b.Groups[jg.Name]; ok {
+		if sameGroup(g, jg) {
+			return nil
+		}
+		return fmt.Errorf("already defined group %q", jg.Name)
+	}
+
+	gb := b.c.NewBuilder()
+	gb.isGroup = true
+	gb.parent = b
+	for _, inner := range jg.Groups {
+		if err := gb.defineGroup(inner); err != nil {
+			return fmt.Errorf("group %q: %w", jg.Name, err)
+		}
+	}
+
+	for i, js := range jg.Steps {
+		errFn := func(err error) error {
+			return fmt.Errorf("group %q step #%v of %v (%v): %w", jg.Name, i+1, len(jg.Steps), js.Action, err)
+		}
+		switch js.Action {
+		case "AddNode":
+			var args []Arg
+			for _, ja := range js.Args {
+				arg, err := ja.toArg()
+				if err != nil {
+					return errFn(err)
+				}
+				args = append(args, arg)
+			}
+			gb = gb.addNode(js.Node, args)
+		case "Connect":
+			gb = gb.Connect(js.From, js.To)
+		case "Input":
+			var opts []InputOption
+			if js.Type != "" {
+				opts = append(opts, InputType(js.Type))
+			}
+			if js.Optional {
+				opts = append(opts, InputOptional())
+			}
+			if js.Default != nil {
+				arg, err := js.Default.toArg()
+				if err != nil {
+					return errFn(err)
+				}
+				opts = append(opts, InputDefault(arg.defaultValue()))
+			}
+			gb = gb.Input(js.Input, js.To, opts...)
+		case "Output":
+			gb = gb.Output(js.From, js.Output)
+		default:
+			return errFn(errors.New("unknown action"))
+		}
+		if len(gb.errs) > 0 {
+			return errFn(errors.Join(gb.errs...))
+		}
+	}
+
+	b.Groups[jg.Name] = gb
+	return nil
+}
+
+// sameGroup reports whether the group definition g is identical to jg.
+func sameGroup(g *Builder, jg *jsonGroup) bool {
+	existing, _ := json.Marshal(g.toJSONGroup(jg.Name))
+	loaded, _ := json.Marshal(jg)
+	return bytes.Equal(existing, loaded)
+}
+
+// defaultValue returns the value of the Arg as accepted by InputDefault.
+func (a Arg) defaultValue() any {
+	switch a.kind {
+	case argScalar:
+		return a.x
+	case argVector:
+		return a.v
+	default:
+		return a.s
+	}
+}
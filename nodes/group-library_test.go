@@ -0,0 +1,203 @@
+package nodes
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSaveGroup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wire.json")
+	b := fakeBuilder().
+		NewGroup("Wire", func(b *Builder) *Builder {
+			return b.AddNode("Helix.h", Enum("direction", "Clockwise")).
+				Input("segments", "Helix.h.segments", InputDefault(24)).
+				Output("Helix.h.out_mesh", "out_mesh")
+		})
+	if err := b.SaveGroup("Wire", path); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{
+  "version": 1,
+  "groups": [
+    {
+      "name": "Wire",
+      "steps": [
+        {
+          "action": "AddNode",
+          "node": "Helix.h",
+          "args": [
+            {
+              "name": "direction",
+              "enum": "Clockwise"
+            }
+          ]
+        },
+        {
+          "action": "Input",
+          "input": "segments",
+          "to": "Helix.h.segments",
+          "type": "scalar",
+          "optional": true,
+          "default": {
+            "scalar": 24
+          }
+        },
+        {
+          "action": "Output",
+          "from": "Helix.h.out_mesh",
+          "output": "out_mesh"
+        }
+      ]
+    }
+  ]
+}
+`
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Errorf("SaveGroup mismatch (-want +got):\n%v", diff)
+	}
+}
+
+func TestLoadGroups(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "coil-pair.json")
+	orig := fakeCoilPairBuilder(t)
+	if err := orig.SaveGroup("CoilPair", path); err != nil {
+		t.Fatal(err)
+	}
+
+	b := fakeBuilder().LoadGroups(path)
+	if len(b.errs) > 0 {
+		t.Fatal(b.Errors())
+	}
+
+	wantInfo, err := orig.GroupInfo("CoilPair")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotInfo, err := b.GroupInfo("CoilPair")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(wantInfo, gotInfo); diff != "" {
+		t.Errorf("GroupInfo mismatch (-want +got):\n%v", diff)
+	}
+
+	origDesign, err := orig.
+		Connect("MakeScalar.angle.x", "CoilPair.cp.angle").
+		Connect("CoilPair.cp.out_mesh", "MergeMeshes.top.mesh_a").
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	design, err := fakeBuilder().
+		LoadGroups(path).
+		AddNode("MakeScalar.angle").
+		AddNode("CoilPair.cp", Scalar("segments", 12)).
+		AddNode("MergeMeshes.top").
+		Connect("MakeScalar.angle.x", "CoilPair.cp.angle").
+		Connect("CoilPair.cp.out_mesh", "MergeMeshes.top.mesh_a").
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(origDesign.String(), design.String()); diff != "" {
+		t.Errorf("loaded group design mismatch (-want +got):\n%v", diff)
+	}
+
+	// Loading the same groups again is a no-op.
+	if b = b.LoadGroups(path); len(b.errs) > 0 {
+		t.Errorf("LoadGroups again: %v", b.Errors())
+	}
+}
+
+func TestSaveGroup_ExternalGroups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pair.json")
+	b := fakeWireBuilder(t).
+		NewGroup("Pair", func(b *Builder) *Builder {
+			return b.AddNode("Wire.a", Vector("size", Vec3{1, 1, 1})).
+				Input("segments", "Wire.a.segments").
+				Output("Wire.a.out_mesh", "out_mesh")
+		})
+	if err := b.SaveGroup("Pair", path); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := fakeBuilder().LoadGroups(path)
+	if diff := cmp.Diff([]string{"Pair", "Wire"}, loaded.GroupNames()); diff != "" {
+		t.Errorf("GroupNames mismatch (-want +got):\n%v", diff)
+	}
+	if _, err := loaded.AddNode("Pair.p", Scalar("segments", 5)).Build(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A different group of the same name is an error.
+	conflict := fakeBuilder().
+		NewGroup("Wire", func(b *Builder) *Builder {
+			return b.AddNode("Helix.h").Output("Helix.h.out_mesh", "out_mesh")
+		}).
+		LoadGroups(path)
+	if errs := conflict.Errors(); len(errs) != 1 || !strings.Contains(errs[0].Error(), `already defined group "Wire"`) {
+		t.Errorf("errs = %v, want already defined group", errs)
+	}
+}
+
+func TestLoadGroups_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		wantErr string
+	}{
+		{
+			name:    "bad version",
+			file:    `{"version": 2, "groups": []}`,
+			wantErr: "unsupported group library version 2",
+		},
+		{
+			name:    "bad action",
+			file:    `{"version": 1, "groups": [{"name": "G", "steps": [{"action": "Remove"}]}]}`,
+			wantErr: `group "G" step #1 of 1 (Remove): unknown action`,
+		},
+		{
+			name:    "bad arg",
+			file:    `{"version": 1, "groups": [{"name": "G", "steps": [{"action": "AddNode", "node": "Helix.h", "args": [{"name": "segments"}]}]}]}`,
+			wantErr: `arg "segments" must have exactly one of`,
+		},
+		{
+			name:    "bad input",
+			file:    `{"version": 1, "groups": [{"name": "G", "steps": [{"action": "AddNode", "node": "Helix.h"}, {"action": "Input", "input": "s", "to": "Helix.h.segments", "type": "vec3"}]}]}`,
+			wantErr: `declared type "vec3" does not match type "scalar"`,
+		},
+		{
+			name:    "later group fails",
+			file:    `{"version": 1, "groups": [{"name": "A", "steps": [{"action": "AddNode", "node": "Helix.h"}]}, {"name": "G", "steps": [{"action": "AddNode", "node": "A.a"}, {"action": "Remove"}]}]}`,
+			wantErr: `group "G" step #2 of 2 (Remove): unknown action`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "groups.json")
+			if err := os.WriteFile(path, []byte(tt.file), 0644); err != nil {
+				t.Fatal(err)
+			}
+			b := fakeBuilder().LoadGroups(path)
+			if errs := b.Errors(); len(errs) != 1 || !strings.Contains(errs[0].Error(), tt.wantErr) {
+				t.Errorf("errs = %v, want %v", errs, tt.wantErr)
+			}
+			for _, name := range []string{"A", "G"} {
+				if _, ok := b.Groups[name]; ok {
+					t.Errorf("group %v was defined despite the error", name)
+				}
+			}
+		})
+	}
+}
@@ -11,7 +11,7 @@ import (
 // and an instance name, then the creation of the group is immediately followed by
 // a call to `AddNode` using that same group and instance name. Otherwise, if no dot
 // is included, the `fullName` will be used as the name of the group.
-func (b *Builder) NewGroup(fullName string, fn func(b *Builder) *Builder, args ...string) *Builder {
+func (b *Builder) NewGroup(fullName string, fn func(b *Builder) *Builder, args ...any) *Builder {
 	var hasInstanceName bool
 	groupName := fullName
 	if parts := strings.Split(fullName, "."); len(parts) > 1 {
@@ -30,6 +30,7 @@ func (b *Builder) NewGroup(fullName string, fn func(b *Builder) *Builder, args .
 
 	gb := b.c.NewBuilder()
 	gb.isGroup = true
+	gb.parent = b
 	if b.c.debug {
 		log.Printf("NewGroup(%q) calling builder fn(gb)", groupName)
 	}
@@ -50,32 +51,168 @@ func (b *Builder) NewGroup(fullName string, fn func(b *Builder) *Builder, args .
 	return b
 }
 
+// lookupGroup returns the definition of the named group type as seen from
+// within group g: a group defined within g itself or within one of its ancestors.
+func (g *Builder) lookupGroup(groupType string) (*Builder, bool) {
+	for s := g; s != nil; s = s.parent {
+		if def, ok := s.Groups[groupType]; ok {
+			return def, true
+		}
+	}
+	return nil, false
+}
+
+// groupOf returns the group definition of the named group instance
+// (e.g. "CoilPair.coils-1-2" or the nested "Coil.CoilPair.coils-1-2.left").
+func (b *Builder) groupOf(instanceName string) (*Builder, bool) {
+	if g, ok := b.groupInstances[instanceName]; ok {
+		return g, true
+	}
+	g, ok := b.Groups[nodeType(instanceName)]
+	return g, ok
+}
+
 // Input is used within a group to connect one of its inputs to an internal input.
-// It can only be used within a group.
-func (b *Builder) Input(inputName, connectTo string) *Builder {
+// connectTo is either a string or a PortRef. It can only be used within a group.
+// An input connected to several internal inputs is declared by calling Input
+// once for each of them. The opts (e.g. InputDefault(36)) declare the input's
+// data type and default value or mark it optional.
+func (b *Builder) Input(inputName string, connectTo any, opts ...InputOption) *Builder {
 	if !b.isGroup {
-		b.errs = append(b.errs, fmt.Errorf("Input(%q,%q) must only be called within a NewGroup builder", inputName, connectTo))
+		b.errs = append(b.errs, fmt.Errorf("Input(%q,%v) must only be called within a NewGroup builder", inputName, connectTo))
 		return b
 	}
+	to, err := toPortRef(connectTo)
+	if err != nil {
+		b.errs = append(b.errs, fmt.Errorf("Input(%q,%v): %w", inputName, connectTo, err))
+		return b
+	}
+
+	var input *inputOptions
+	if len(opts) > 0 {
+		input = &inputOptions{}
+		for _, opt := range opts {
+			opt(input)
+		}
+		if err := b.checkInputOptions(inputName, to, input); err != nil {
+			b.errs = append(b.errs, fmt.Errorf("Input(%q,%v): %w", inputName, connectTo, err))
+			return b
+		}
+	}
 
 	b.groupRecorder = append(b.groupRecorder, &recorder{
 		action: "Input",
-		args:   []string{inputName, connectTo},
+		port:   inputName,
+		to:     to,
+		input:  input,
 	})
 	return b
 }
 
+// InputOption declares a property of a group input. See Input.
+type InputOption func(*inputOptions)
+
+type inputOptions struct {
+	dataType string
+	optional bool
+	def      *Arg
+	err      error
+}
+
+// InputType declares the data type of a group input (e.g. "scalar", "vec3",
+// "enum", "string", or "mesh"), which must match the internal inputs it is connected to.
+func InputType(dataType string) InputOption {
+	return func(o *inputOptions) { o.dataType = dataType }
+}
+
+// InputOptional marks a group input as optional: it may be left unconnected
+// and unassigned even when CheckUnusedGroupInputs is set, in which case the
+// internal inputs keep their own defaults.
+func InputOptional() InputOption {
+	return func(o *inputOptions) { o.optional = true }
+}
+
+// InputDefault declares the default value of a group input and marks it optional.
+// The value is a float64 (or int) for a scalar input, a Vec3 for a vector input,
+// or a string for any other input (e.g. the selected value of an enum).
+// The default is assigned to the internal inputs whenever an instance of the
+// group neither assigns nor connects the input.
+func InputDefault(value any) InputOption {
+	return func(o *inputOptions) {
+		o.optional = true
+		var arg Arg
+		switch v := value.(type) {
+		case float64:
+			arg = Scalar("", v)
+		case int:
+			arg = Scalar("", float64(v))
+		case Vec3:
+			arg = Vector("", v)
+		case string:
+			arg = Arg{kind: argString, s: v}
+		default:
+			o.err = fmt.Errorf("bad default %v of type %T, want float64, int, Vec3, or string", value, value)
+			return
+		}
+		o.def = &arg
+	}
+}
+
+// checkInputOptions checks the options of a group input against its internal
+// input and against earlier declarations of the same group input, and infers
+// the data type of a scalar or vector default.
+func (b *Builder) checkInputOptions(inputName string, to PortRef, opts *inputOptions) error {
+	if opts.err != nil {
+		return opts.err
+	}
+	if def := opts.def; def != nil {
+		defType := map[argKind]string{argScalar: "scalar", argVector: "vec3"}[def.kind]
+		if opts.dataType == "" {
+			opts.dataType = defType
+		}
+		if defType != "" && defType != opts.dataType {
+			return fmt.Errorf("default %v does not match declared type %q", def.value(), opts.dataType)
+		}
+	}
+	if opts.dataType != "" {
+		if t := b.portDataType(to, false); t != "" && t != opts.dataType {
+			return fmt.Errorf("declared type %q does not match type %q of internal input %q", opts.dataType, t, to)
+		}
+	}
+
+	for _, step := range b.groupRecorder {
+		if step.action != "Input" || step.port != inputName || step.input == nil {
+			continue
+		}
+		prev := step.input
+		if prev.dataType != "" && opts.dataType != "" && prev.dataType != opts.dataType {
+			return fmt.Errorf("declared type %q does not match earlier declared type %q", opts.dataType, prev.dataType)
+		}
+		if prev.def != nil && opts.def != nil && prev.def.value() != opts.def.value() {
+			return fmt.Errorf("default %v does not match earlier default %v", opts.def.value(), prev.def.value())
+		}
+	}
+
+	return nil
+}
+
 // Output is used within a group to connect one of its outputs to the group output.
-// It can only be used within a group.
-func (b *Builder) Output(connectFrom, outputName string) *Builder {
+// connectFrom is either a string or a PortRef. It can only be used within a group.
+func (b *Builder) Output(connectFrom any, outputName string) *Builder {
 	if !b.isGroup {
-		b.errs = append(b.errs, fmt.Errorf("Output(%q,%q) must only be called within a NewGroup builder", connectFrom, outputName))
+		b.errs = append(b.errs, fmt.Errorf("Output(%v,%q) must only be called within a NewGroup builder", connectFrom, outputName))
+		return b
+	}
+	from, err := toPortRef(connectFrom)
+	if err != nil {
+		b.errs = append(b.errs, fmt.Errorf("Output(%v,%q): %w", connectFrom, outputName, err))
 		return b
 	}
 
 	b.groupRecorder = append(b.groupRecorder, &recorder{
 		action: "Output",
-		args:   []string{connectFrom, outputName},
+		from:   from,
+		port:   outputName,
 	})
 	return b
 }
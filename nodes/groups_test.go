@@ -0,0 +1,276 @@
+package nodes
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	lua "github.com/yuin/gopher-lua"
+)
+
+func TestNestedGroups(t *testing.T) {
+	b := fakeCoilPairBuilder(t).
+		Connect("MakeScalar.angle.x", "CoilPair.cp.angle").
+		Connect("CoilPair.cp.out_mesh", "MergeMeshes.top.mesh_a")
+	design, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var helixes []string
+	for _, name := range b.NodeOrder {
+		if strings.HasPrefix(name, "Helix.") {
+			helixes = append(helixes, name)
+		}
+	}
+	want := []string{
+		"Helix.Wire.Coil.CoilPair.cp.left.inner.h",
+		"Helix.Wire.Coil.CoilPair.cp.left.outer.h",
+		"Helix.Wire.Coil.CoilPair.cp.right.inner.h",
+		"Helix.Wire.Coil.CoilPair.cp.right.outer.h",
+	}
+	if diff := cmp.Diff(want, helixes); diff != "" {
+		t.Fatalf("Helix nodes mismatch (-want +got):\n%v", diff)
+	}
+
+	angleIdx := b.Nodes["MakeScalar.angle"].Index
+	for _, name := range helixes {
+		n := b.Nodes[name]
+		if got := n.Inputs[1].Props["default"]; got != lua.LNumber(12) {
+			t.Errorf("%v segments = %v, want 12", name, got)
+		}
+		if conn := n.Inputs[0].Kind.Connection; conn == nil || conn.NodeIdx != angleIdx {
+			t.Errorf("%v start_angle connection = %+v, want from node %v", name, conn, angleIdx)
+		}
+	}
+
+	pair := b.Nodes["MergeMeshes.CoilPair.cp.pair"]
+	if conn := b.Nodes["MergeMeshes.top"].Inputs[0].Kind.Connection; conn == nil || conn.NodeIdx != pair.Index {
+		t.Errorf("MergeMeshes.top.mesh_a connection = %+v, want from node %v", conn, pair.Index)
+	}
+	if got, want := len(design.Graph.Nodes), 9; got != want {
+		t.Errorf("got %v nodes, want %v", got, want)
+	}
+}
+
+func TestNestedGroups_Scoping(t *testing.T) {
+	b := fakeCoilPairBuilder(t).AddNode("Wire.top")
+	var unknown *UnknownNodeTypeError
+	if len(b.errs) != 1 || !errors.As(b.errs[0], &unknown) {
+		t.Errorf("errs = %v, want Wire to be unknown outside of CoilPair", b.errs)
+	}
+}
+
+func TestNestedGroups_UnusedInput(t *testing.T) {
+	b := fakeCoilPairBuilder(t).Connect("CoilPair.cp.out_mesh", "MergeMeshes.top.mesh_a")
+	_, err := b.Build()
+	if err == nil || !strings.Contains(err.Error(), `unused group input port: "Helix.Wire.Coil.CoilPair.cp.left.inner.h.start_angle"`) {
+		t.Errorf("Build err = %v, want unused angle inputs", err)
+	}
+}
+
+func TestNestedGroups_RemoveAndClone(t *testing.T) {
+	b := fakeCoilPairBuilder(t).
+		Connect("MakeScalar.angle.x", "CoilPair.cp.angle").
+		Connect("CoilPair.cp.out_mesh", "MergeMeshes.top.mesh_a")
+
+	clone := b.Clone().RemoveNode("Coil.CoilPair.cp.left")
+	if len(clone.errs) > 0 {
+		t.Fatalf("unexpected errors: %v", clone.errs)
+	}
+	if got, want := len(clone.NodeOrder), len(b.NodeOrder)-3; got != want {
+		t.Errorf("got %v nodes after RemoveNode, want %v", got, want)
+	}
+
+	clone.AddNode("CoilPair.cp2", Scalar("segments", 6)).
+		Connect("MakeScalar.angle.x", "CoilPair.cp2.angle").
+		Connect("CoilPair.cp2.out_mesh", "MergeMeshes.top.mesh_b")
+	if _, err := clone.Build(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := b.Nodes["Helix.Wire.Coil.CoilPair.cp2.left.inner.h"]; ok {
+		t.Error("the clone's new nodes were added to the original builder")
+	}
+}
+
+func TestOptionalGroupInputs(t *testing.T) {
+	tests := []struct {
+		name         string
+		args         []any
+		connect      bool
+		wantErr      string
+		wantSegments float64
+		wantAngle    float64
+	}{
+		{
+			name:    "required input unused",
+			wantErr: `unused group input port: "Helix.Wire.w.h.size"`,
+		},
+		{
+			name:         "defaults",
+			args:         []any{Vector("size", Vec3{1, 1, 1})},
+			wantSegments: 24,
+		},
+		{
+			name:         "assigned",
+			args:         []any{Vector("size", Vec3{1, 1, 1}), Scalar("segments", 6), Scalar("angle", 90)},
+			wantSegments: 6,
+			wantAngle:    90,
+		},
+		{
+			name:         "connected",
+			args:         []any{Vector("size", Vec3{1, 1, 1})},
+			connect:      true,
+			wantSegments: 24,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := fakeWireBuilder(t).AddNode("Wire.w", tt.args...)
+			if tt.connect {
+				b = b.AddNode("MakeScalar.s").Connect("MakeScalar.s.x", "Wire.w.segments")
+			}
+			_, err := b.Build()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Build err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			helix := b.Nodes["Helix.Wire.w.h"]
+			if got := helix.Inputs[1].Props["default"]; got != lua.LNumber(tt.wantSegments) {
+				t.Errorf("segments = %v, want %v", got, tt.wantSegments)
+			}
+			if got := helix.Inputs[0].Props["default"]; got != lua.LNumber(tt.wantAngle) {
+				t.Errorf("start_angle = %v, want %v", got, tt.wantAngle)
+			}
+			if conn := helix.Inputs[1].Kind.Connection; tt.connect != (conn != nil) {
+				t.Errorf("segments connection = %+v, want connected=%v", conn, tt.connect)
+			}
+		})
+	}
+}
+
+func TestOptionalGroupInputs_Nested(t *testing.T) {
+	b := fakeWireBuilder(t).
+		NewGroup("Pair", func(b *Builder) *Builder {
+			return b.AddNode("Wire.a", Vector("size", Vec3{1, 1, 1})).
+				Input("segments", "Wire.a.segments", InputDefault(10)).
+				Output("Wire.a.out_mesh", "out_mesh")
+		}).
+		AddNode("Pair.p")
+	if _, err := b.Build(); err != nil {
+		t.Fatal(err)
+	}
+	if got := b.Nodes["Helix.Wire.Pair.p.a.h"].Inputs[1].Props["default"]; got != lua.LNumber(10) {
+		t.Errorf("segments = %v, want 10", got)
+	}
+}
+
+func TestInputOptionErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		fn      func(b *Builder) *Builder
+		wantErr string
+	}{
+		{
+			name: "type mismatch",
+			fn: func(b *Builder) *Builder {
+				return b.AddNode("Helix.h").Input("segments", "Helix.h.segments", InputType("mesh"))
+			},
+			wantErr: `declared type "mesh" does not match type "scalar" of internal input "Helix.h.segments"`,
+		},
+		{
+			name: "default mismatch",
+			fn: func(b *Builder) *Builder {
+				return b.AddNode("Helix.h").Input("size", "Helix.h.size", InputType("vec3"), InputDefault(3))
+			},
+			wantErr: `default 3 does not match declared type "vec3"`,
+		},
+		{
+			name: "bad default",
+			fn: func(b *Builder) *Builder {
+				return b.AddNode("Helix.h").Input("segments", "Helix.h.segments", InputDefault(true))
+			},
+			wantErr: "bad default true of type bool",
+		},
+		{
+			name: "conflicting defaults",
+			fn: func(b *Builder) *Builder {
+				return b.AddNode("Helix.a").AddNode("Helix.b").
+					Input("segments", "Helix.a.segments", InputDefault(3)).
+					Input("segments", "Helix.b.segments", InputDefault(4))
+			},
+			wantErr: "default 4 does not match earlier default 3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := fakeBuilder().NewGroup("G", tt.fn)
+			errs := b.Groups["G"].Errors()
+			if len(errs) != 1 || !strings.Contains(errs[0].Error(), tt.wantErr) {
+				t.Errorf("errs = %v, want %v", errs, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGroupInfo(t *testing.T) {
+	b := fakeCoilPairBuilder(t)
+
+	if diff := cmp.Diff([]string{"CoilPair"}, b.GroupNames()); diff != "" {
+		t.Errorf("GroupNames mismatch (-want +got):\n%v", diff)
+	}
+
+	got, err := b.GroupInfo("CoilPair")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &GroupInfo{
+		Name: "CoilPair",
+		Inputs: []GroupPort{
+			{Name: "segments", DataType: "scalar", Ports: []string{"Coil.left.segments", "Coil.right.segments"}},
+			{Name: "angle", DataType: "scalar", Ports: []string{"Coil.left.angle", "Coil.right.angle"}},
+		},
+		Outputs: []GroupPort{
+			{Name: "out_mesh", DataType: "mesh", Ports: []string{"MergeMeshes.pair.out_mesh"}},
+		},
+		Groups: []string{"Coil", "Wire"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("GroupInfo mismatch (-want +got):\n%v", diff)
+	}
+
+	got, err = fakeWireBuilder(t).GroupInfo("Wire")
+	if err != nil {
+		t.Fatal(err)
+	}
+	segments := Scalar("segments", 24)
+	want = &GroupInfo{
+		Name: "Wire",
+		Inputs: []GroupPort{
+			{Name: "segments", DataType: "scalar", Optional: true, Default: &segments, Ports: []string{"Helix.h.segments"}},
+			{Name: "angle", DataType: "scalar", Optional: true, Ports: []string{"Helix.h.start_angle"}},
+			{Name: "size", DataType: "vec3", Ports: []string{"Helix.h.size"}},
+		},
+		Outputs: []GroupPort{
+			{Name: "out_mesh", DataType: "mesh", Ports: []string{"Helix.h.out_mesh"}},
+		},
+		Groups: []string{},
+	}
+	if diff := cmp.Diff(want, got, cmp.AllowUnexported(Arg{})); diff != "" {
+		t.Errorf("GroupInfo mismatch (-want +got):\n%v", diff)
+	}
+
+	var unknown *UnknownNodeTypeError
+	if _, err := b.GroupInfo("Coil"); !errors.As(err, &unknown) {
+		t.Errorf("GroupInfo(Coil) err = %v, want UnknownNodeTypeError", err)
+	}
+}
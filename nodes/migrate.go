@@ -0,0 +1,434 @@
+package nodes
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/gmlewis/go-bjk/ast"
+)
+
+// MigrationKind describes how a node in a design differs from the node
+// declared by the current Lua node library.
+type MigrationKind string
+
+const (
+	UnknownNode   MigrationKind = "unknown node"
+	RenamedInput  MigrationKind = "renamed input"
+	RemovedInput  MigrationKind = "removed input"
+	MissingInput  MigrationKind = "missing input"
+	RetypedInput  MigrationKind = "retyped input"
+	RenamedOutput MigrationKind = "renamed output"
+	RemovedOutput MigrationKind = "removed output"
+	MissingOutput MigrationKind = "missing output"
+	RetypedOutput MigrationKind = "retyped output"
+	// BrokenConnection is an input connected to an output whose new
+	// data type differs from the input's.
+	BrokenConnection MigrationKind = "broken connection"
+)
+
+// MigrationIssue is a single difference found by CheckDesign or Migrate.
+type MigrationIssue struct {
+	NodeIdx uint64
+	OpName  string
+	Kind    MigrationKind
+	// Name is the name of the input or output in the design.
+	Name string
+	// NewName is the name declared by the node library for renamed ports.
+	NewName string
+	// Inferred reports whether the rename was inferred from the ports' data
+	// types rather than listed in Client.Migrations. Inferred renames are
+	// never fixed by Migrate.
+	Inferred bool
+	// OldType and NewType are set for retyped ports.
+	OldType string
+	NewType string
+	// Fixed reports whether Migrate updated the design to resolve the issue.
+	Fixed bool
+}
+
+func (mi *MigrationIssue) String() string {
+	var detail string
+	switch {
+	case mi.Kind == UnknownNode:
+	case mi.NewName != "":
+		detail = fmt.Sprintf(" %q -> %q", mi.Name, mi.NewName)
+		if mi.Inferred {
+			detail += " (inferred)"
+		}
+	case mi.OldType != "" || mi.NewType != "":
+		detail = fmt.Sprintf(" %q: %v -> %v", mi.Name, mi.OldType, mi.NewType)
+	default:
+		detail = fmt.Sprintf(" %q", mi.Name)
+	}
+	var fixed string
+	if mi.Fixed {
+		fixed = " (fixed)"
+	}
+	return fmt.Sprintf("node %v (%v): %v%v%v", mi.NodeIdx, mi.OpName, mi.Kind, detail, fixed)
+}
+
+// Migration is a rename of a node's input or output port that applies to
+// designs whose version is older than Before. The Lua node library does not
+// record its renames, so none are built in: add the renames of the nodes in
+// use to Client.Migrations. Renames that are not listed are still inferred
+// when a node has exactly one unknown port and exactly one missing port of
+// the same data type, but they are only reported: Migrate leaves those
+// ports as they are.
+type Migration struct {
+	Before ast.Version `json:"before"`
+	OpName string      `json:"op_name"`
+	// Output is true if the port is an output rather than an input.
+	Output bool   `json:"output,omitempty"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+}
+
+// CheckDesign compares the design against the loaded node library and
+// reports every node input or output that is stale. The design is not modified.
+func (c *Client) CheckDesign(design *ast.BJK) []*MigrationIssue {
+	return migrate(design, c.Nodes, c.Migrations, false)
+}
+
+// Migrate compares the design against the loaded node library and updates
+// it in place: renamed ports are renamed (including connections and external
+// parameters) if they are listed in Client.Migrations, removed ports are dropped, missing ports are added with their
+// default values, and retyped external inputs are reset to their defaults.
+// Inputs connected to a retyped output of a different data type are
+// disconnected and reset to their defaults.
+// Issues that cannot be fixed automatically (e.g. unknown nodes or removed
+// outputs that are still connected) are reported with Fixed=false.
+func (c *Client) Migrate(design *ast.BJK) []*MigrationIssue {
+	return migrate(design, c.Nodes, c.Migrations, true)
+}
+
+func migrate(design *ast.BJK, library map[string]*ast.Node, migrations []*Migration, fix bool) []*MigrationIssue {
+	if design == nil || design.Graph == nil {
+		return nil
+	}
+	g := design.Graph
+	if fix && g.ExternalParameters == nil {
+		g.ExternalParameters = &ast.ExternalParameters{}
+	}
+
+	m := &migrator{g: g, fix: fix, migrations: migrations}
+	for i, n := range g.Nodes {
+		idx := uint64(i)
+		decl, ok := library[n.OpName]
+		if !ok {
+			m.add(&MigrationIssue{NodeIdx: idx, OpName: n.OpName, Kind: UnknownNode})
+			continue
+		}
+		m.migrateInputs(design.Version, idx, n, decl)
+		m.migrateOutputs(design.Version, idx, n, decl)
+	}
+	m.breakRetypedConnections(library)
+
+	if fix {
+		for _, mig := range m.applied {
+			if design.Version.Compare(mig.Before) < 0 {
+				design.Version = mig.Before
+			}
+		}
+	}
+
+	return m.issues
+}
+
+type migrator struct {
+	g          *ast.Graph
+	fix        bool
+	migrations []*Migration
+	issues     []*MigrationIssue
+	applied    []*Migration
+	retyped    []retypedOutput
+}
+
+// retypedOutput is an output whose data type differs from the node library's.
+type retypedOutput struct {
+	nodeIdx  uint64
+	name     string
+	dataType string
+}
+
+func (m *migrator) add(issue *MigrationIssue) {
+	m.issues = append(m.issues, issue)
+}
+
+// renames returns the port renames for the node listed in the migrations and
+// the rename inferred for a single unknown port that matches a single missing port.
+func (m *migrator) renames(version ast.Version, opName string, output bool, unknown, missing map[string]string) (listed, inferred map[string]string) {
+	listed, inferred = map[string]string{}, map[string]string{}
+	for _, mig := range m.migrations {
+		if mig.OpName != opName || mig.Output != output || version.Compare(mig.Before) >= 0 {
+			continue
+		}
+		if _, ok := unknown[mig.From]; !ok {
+			continue
+		}
+		if _, ok := missing[mig.To]; !ok {
+			continue
+		}
+		listed[mig.From] = mig.To
+		delete(unknown, mig.From)
+		delete(missing, mig.To)
+		if m.fix {
+			m.applied = append(m.applied, mig)
+		}
+	}
+
+	if len(unknown) == 1 && len(missing) == 1 {
+		for from, fromType := range unknown {
+			for to, toType := range missing {
+				if fromType == toType {
+					inferred[from] = to
+					delete(unknown, from)
+					delete(missing, to)
+				}
+			}
+		}
+	}
+
+	return listed, inferred
+}
+
+func (m *migrator) migrateInputs(version ast.Version, idx uint64, n, decl *ast.Node) {
+	unknown, missing := map[string]string{}, map[string]string{}
+	for _, input := range n.Inputs {
+		if _, ok := decl.GetInput(input.Name); !ok {
+			unknown[input.Name] = ast.DataTypeToBJK(input.DataType)
+		}
+	}
+	for _, input := range decl.Inputs {
+		if _, ok := n.GetInput(input.Name); !ok {
+			missing[input.Name] = ast.DataTypeToBJK(input.DataType)
+		}
+	}
+
+	renames, inferred := m.renames(version, n.OpName, false, unknown, missing)
+	inferredFrom := map[string]string{}
+	for _, input := range n.Inputs {
+		if to, ok := renames[input.Name]; ok {
+			m.add(&MigrationIssue{NodeIdx: idx, OpName: n.OpName, Kind: RenamedInput, Name: input.Name, NewName: to, Fixed: m.fix})
+			if m.fix {
+				m.renameParam(idx, input.Name, to)
+				input.Name = to
+			}
+		} else if to, ok := inferred[input.Name]; ok {
+			m.add(&MigrationIssue{NodeIdx: idx, OpName: n.OpName, Kind: RenamedInput, Name: input.Name, NewName: to, Inferred: true})
+			inferredFrom[to] = input.Name
+		}
+	}
+
+	var inputs []*ast.Input
+	for _, input := range n.Inputs {
+		if _, ok := unknown[input.Name]; ok {
+			m.add(&MigrationIssue{NodeIdx: idx, OpName: n.OpName, Kind: RemovedInput, Name: input.Name, Fixed: m.fix})
+			if m.fix {
+				m.removeParam(idx, input.Name)
+			}
+			continue
+		}
+		inputs = append(inputs, input)
+	}
+
+	// Rebuild the inputs in the order declared by the node library.
+	var result []*ast.Input
+	for _, want := range decl.Inputs {
+		wantType := ast.DataTypeToBJK(want.DataType)
+		i := slices.IndexFunc(inputs, func(input *ast.Input) bool { return input.Name == want.Name })
+		if from, ok := inferredFrom[want.Name]; ok {
+			// Inferred renames are only reported, so the old input keeps its place.
+			i = slices.IndexFunc(inputs, func(input *ast.Input) bool { return input.Name == from })
+			result = append(result, inputs[i])
+			inputs = slices.Delete(inputs, i, i+1)
+			continue
+		}
+		if i < 0 {
+			if _, ok := missing[want.Name]; !ok {
+				continue // the input was renamed but not fixed.
+			}
+			issue := &MigrationIssue{NodeIdx: idx, OpName: n.OpName, Kind: MissingInput, Name: want.Name}
+			m.add(issue)
+			input := &ast.Input{Name: want.Name, DataType: wantType, Kind: ast.DependencyKind{External: &ast.External{}}}
+			issue.Fixed = m.fix && m.setDefault(idx, want)
+			result = append(result, input)
+			continue
+		}
+
+		input := inputs[i]
+		inputs = slices.Delete(inputs, i, i+1)
+		if gotType := ast.DataTypeToBJK(input.DataType); gotType != wantType {
+			issue := &MigrationIssue{NodeIdx: idx, OpName: n.OpName, Kind: RetypedInput, Name: input.Name, OldType: gotType, NewType: wantType}
+			m.add(issue)
+			if m.fix && input.Kind.Connection == nil {
+				input.DataType = wantType
+				m.removeParam(idx, input.Name)
+				issue.Fixed = m.setDefault(idx, want)
+			}
+		}
+		result = append(result, input)
+	}
+
+	if m.fix {
+		n.Inputs = result
+	}
+}
+
+func (m *migrator) migrateOutputs(version ast.Version, idx uint64, n, decl *ast.Node) {
+	unknown, missing := map[string]string{}, map[string]string{}
+	for _, output := range n.Outputs {
+		if _, ok := decl.GetOutput(output.Name); !ok {
+			unknown[output.Name] = ast.DataTypeToBJK(output.DataType)
+		}
+	}
+	for _, output := range decl.Outputs {
+		if _, ok := n.GetOutput(output.Name); !ok {
+			missing[output.Name] = ast.DataTypeToBJK(output.DataType)
+		}
+	}
+
+	renames, inferred := m.renames(version, n.OpName, true, unknown, missing)
+	for _, output := range n.Outputs {
+		if to, ok := inferred[output.Name]; ok {
+			m.add(&MigrationIssue{NodeIdx: idx, OpName: n.OpName, Kind: RenamedOutput, Name: output.Name, NewName: to, Inferred: true})
+			continue
+		}
+		if to, ok := renames[output.Name]; ok {
+			m.add(&MigrationIssue{NodeIdx: idx, OpName: n.OpName, Kind: RenamedOutput, Name: output.Name, NewName: to, Fixed: m.fix})
+			if m.fix {
+				m.renameConnections(idx, output.Name, to)
+				if n.ReturnValue != nil && *n.ReturnValue == output.Name {
+					n.ReturnValue = &to
+				}
+				output.Name = to
+			}
+		}
+	}
+
+	var result []*ast.Output
+	for _, output := range n.Outputs {
+		if _, ok := unknown[output.Name]; ok {
+			issue := &MigrationIssue{NodeIdx: idx, OpName: n.OpName, Kind: RemovedOutput, Name: output.Name}
+			m.add(issue)
+			if m.fix && !m.isConnected(idx, output.Name) {
+				issue.Fixed = true
+				if n.ReturnValue != nil && *n.ReturnValue == output.Name {
+					n.ReturnValue = decl.ReturnValue
+				}
+				continue
+			}
+		} else if want, ok := decl.GetOutput(output.Name); ok {
+			gotType, wantType := ast.DataTypeToBJK(output.DataType), ast.DataTypeToBJK(want.DataType)
+			if gotType != wantType {
+				m.add(&MigrationIssue{NodeIdx: idx, OpName: n.OpName, Kind: RetypedOutput, Name: output.Name, OldType: gotType, NewType: wantType, Fixed: m.fix})
+				m.retyped = append(m.retyped, retypedOutput{nodeIdx: idx, name: output.Name, dataType: wantType})
+				if m.fix {
+					output.DataType = wantType
+				}
+			}
+		}
+		result = append(result, output)
+	}
+
+	for _, output := range decl.Outputs {
+		if _, ok := missing[output.Name]; ok {
+			m.add(&MigrationIssue{NodeIdx: idx, OpName: n.OpName, Kind: MissingOutput, Name: output.Name, Fixed: m.fix})
+			result = append(result, &ast.Output{Name: output.Name, DataType: ast.DataTypeToBJK(output.DataType)})
+		}
+	}
+
+	if m.fix {
+		n.Outputs = result
+	}
+}
+
+// breakRetypedConnections reports (and, when fixing, disconnects) the inputs
+// connected to a retyped output whose data type no longer matches.
+func (m *migrator) breakRetypedConnections(library map[string]*ast.Node) {
+	for _, ro := range m.retyped {
+		for i, n := range m.g.Nodes {
+			for _, input := range n.Inputs {
+				conn := input.Kind.Connection
+				if conn == nil || conn.NodeIdx != ro.nodeIdx || conn.ParamName != ro.name {
+					continue
+				}
+				inputType := ast.DataTypeToBJK(input.DataType)
+				var decl *ast.Input
+				if libNode, ok := library[n.OpName]; ok {
+					if decl, ok = libNode.GetInput(input.Name); ok {
+						inputType = ast.DataTypeToBJK(decl.DataType)
+					}
+				}
+				if inputType == ro.dataType {
+					continue
+				}
+
+				idx := uint64(i)
+				issue := &MigrationIssue{NodeIdx: idx, OpName: n.OpName, Kind: BrokenConnection, Name: input.Name, OldType: ro.dataType, NewType: inputType}
+				m.add(issue)
+				if m.fix && decl != nil {
+					input.DataType = inputType
+					input.Kind = ast.DependencyKind{External: &ast.External{}}
+					issue.Fixed = m.setDefault(idx, decl)
+				}
+			}
+		}
+	}
+}
+
+// setDefault adds an external parameter with the declared default value
+// of the input (except meshes) and reports whether it succeeded.
+func (m *migrator) setDefault(idx uint64, decl *ast.Input) bool {
+	if ast.DataTypeToBJK(decl.DataType) == "BJK_MESH" {
+		return true
+	}
+	ve, err := getValueEnum(decl)
+	if err != nil {
+		return false
+	}
+	ep := m.g.ExternalParameters
+	ep.ParamValues = append(ep.ParamValues, &ast.ParamValue{NodeIdx: idx, ParamName: decl.Name, ValueEnum: *ve})
+	return true
+}
+
+func (m *migrator) renameParam(idx uint64, from, to string) {
+	if m.g.ExternalParameters == nil {
+		return
+	}
+	for _, pv := range m.g.ExternalParameters.ParamValues {
+		if pv.NodeIdx == idx && pv.ParamName == from {
+			pv.ParamName = to
+		}
+	}
+}
+
+func (m *migrator) removeParam(idx uint64, name string) {
+	if m.g.ExternalParameters == nil {
+		return
+	}
+	ep := m.g.ExternalParameters
+	ep.ParamValues = slices.DeleteFunc(ep.ParamValues, func(pv *ast.ParamValue) bool {
+		return pv.NodeIdx == idx && pv.ParamName == name
+	})
+}
+
+func (m *migrator) renameConnections(idx uint64, from, to string) {
+	for _, n := range m.g.Nodes {
+		for _, input := range n.Inputs {
+			if conn := input.Kind.Connection; conn != nil && conn.NodeIdx == idx && conn.ParamName == from {
+				conn.ParamName = to
+			}
+		}
+	}
+}
+
+func (m *migrator) isConnected(idx uint64, output string) bool {
+	for _, n := range m.g.Nodes {
+		for _, input := range n.Inputs {
+			if conn := input.Kind.Connection; conn != nil && conn.NodeIdx == idx && conn.ParamName == output {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,269 @@
+package nodes
+
+import (
+	"testing"
+
+	"github.com/gmlewis/go-bjk/ast"
+	"github.com/google/go-cmp/cmp"
+)
+
+func migrateLibrary() map[string]*ast.Node {
+	return map[string]*ast.Node{
+		"MakeScalar": {
+			OpName:  "MakeScalar",
+			Inputs:  []*ast.Input{scalarInput("value", 0)},
+			Outputs: []*ast.Output{{Name: "x", DataType: "scalar"}},
+		},
+		"Helix": {
+			OpName: "Helix",
+			Inputs: []*ast.Input{
+				scalarInput("turns", 1),
+				scalarInput("segments", 36),
+				scalarInput("height", 1),
+			},
+			Outputs: []*ast.Output{{Name: "out_mesh", DataType: "mesh"}},
+		},
+	}
+}
+
+// migrateDesign has a MakeScalar whose input "x" and output "value" were
+// swapped by the library, and a Helix whose vector "size" input no longer
+// exists and that is missing the new scalar "height" input.
+func migrateDesign() *ast.BJK {
+	ext := func(name, dataType string) *ast.Input {
+		return &ast.Input{Name: name, DataType: dataType, Kind: ast.DependencyKind{External: &ast.External{}}}
+	}
+	dn := uint64(1)
+	return &ast.BJK{
+		Version: ast.Version{Minor: 1},
+		Graph: &ast.Graph{
+			Nodes: []*ast.Node{
+				{
+					OpName:  "MakeScalar",
+					Inputs:  []*ast.Input{ext("x", "BJK_SCALAR")},
+					Outputs: []*ast.Output{{Name: "value", DataType: "BJK_SCALAR"}},
+				},
+				{
+					OpName: "Helix",
+					Inputs: []*ast.Input{
+						{Name: "turns", DataType: "BJK_SCALAR", Kind: ast.DependencyKind{Connection: &ast.Connection{NodeIdx: 0, ParamName: "value"}}},
+						ext("size", "BJK_VECTOR"),
+						ext("segments", "BJK_SCALAR"),
+					},
+					Outputs: []*ast.Output{{Name: "out_mesh", DataType: "BJK_MESH"}},
+				},
+			},
+			DefaultNode: &dn,
+			ExternalParameters: &ast.ExternalParameters{
+				ParamValues: []*ast.ParamValue{
+					{NodeIdx: 0, ParamName: "x", ValueEnum: ast.ValueEnum{Scalar: &ast.ScalarValue{X: 3}}},
+					{NodeIdx: 1, ParamName: "size", ValueEnum: ast.ValueEnum{Vector: &ast.VectorValue{X: 2, Y: 2, Z: 2}}},
+					{NodeIdx: 1, ParamName: "segments", ValueEnum: ast.ValueEnum{Scalar: &ast.ScalarValue{X: 24}}},
+				},
+			},
+		},
+	}
+}
+
+func TestMigrate_Check(t *testing.T) {
+	design := migrateDesign()
+	want := design.String()
+
+	got := migrate(design, migrateLibrary(), nil, false)
+	wantIssues := []string{
+		`node 0 (MakeScalar): renamed input "x" -> "value" (inferred)`,
+		`node 0 (MakeScalar): renamed output "value" -> "x" (inferred)`,
+		`node 1 (Helix): removed input "size"`,
+		`node 1 (Helix): missing input "height"`,
+	}
+	if diff := cmp.Diff(wantIssues, issueStrings(got)); diff != "" {
+		t.Errorf("migrate mismatch (-want +got):\n%v", diff)
+	}
+
+	if diff := cmp.Diff(want, design.String()); diff != "" {
+		t.Errorf("check modified the design (-want +got):\n%v", diff)
+	}
+}
+
+// scalarMigrations lists the renames of the MakeScalar ports of migrateDesign.
+var scalarMigrations = []*Migration{
+	{Before: ast.Version{Minor: 2}, OpName: "MakeScalar", From: "x", To: "value"},
+	{Before: ast.Version{Minor: 2}, OpName: "MakeScalar", Output: true, From: "value", To: "x"},
+}
+
+func TestMigrate_Fix(t *testing.T) {
+	design := migrateDesign()
+	got := migrate(design, migrateLibrary(), scalarMigrations, true)
+	for _, issue := range got {
+		if !issue.Fixed {
+			t.Errorf("issue not fixed: %v", issue)
+		}
+	}
+
+	g := design.Graph
+	if diff := cmp.Diff([]string{"value"}, g.Nodes[0].GetInputs()); diff != "" {
+		t.Errorf("MakeScalar inputs mismatch (-want +got):\n%v", diff)
+	}
+	if diff := cmp.Diff([]string{"x"}, g.Nodes[0].GetOutputs()); diff != "" {
+		t.Errorf("MakeScalar outputs mismatch (-want +got):\n%v", diff)
+	}
+	if diff := cmp.Diff([]string{"turns", "segments", "height"}, g.Nodes[1].GetInputs()); diff != "" {
+		t.Errorf("Helix inputs mismatch (-want +got):\n%v", diff)
+	}
+	if got := g.Nodes[1].Inputs[0].Kind.Connection.ParamName; got != "x" {
+		t.Errorf("Helix.turns connection = %q, want %q", got, "x")
+	}
+
+	var params []string
+	for _, pv := range g.ExternalParameters.ParamValues {
+		params = append(params, genKey(int(pv.NodeIdx), pv.ParamName)+"="+pv.ValueEnum.String())
+	}
+	wantParams := []string{"0,value=Scalar(3.0)", "1,segments=Scalar(24.0)", "1,height=Scalar(1.0)"}
+	if diff := cmp.Diff(wantParams, params); diff != "" {
+		t.Errorf("param values mismatch (-want +got):\n%v", diff)
+	}
+
+	if err := design.Validate(); err != nil {
+		t.Errorf("migrated design is invalid: %v", err)
+	}
+}
+
+func TestMigrate_Inferred(t *testing.T) {
+	design := migrateDesign()
+	got := migrate(design, migrateLibrary(), nil, true)
+	want := []string{
+		`node 0 (MakeScalar): renamed input "x" -> "value" (inferred)`,
+		`node 0 (MakeScalar): renamed output "value" -> "x" (inferred)`,
+		`node 1 (Helix): removed input "size" (fixed)`,
+		`node 1 (Helix): missing input "height" (fixed)`,
+	}
+	if diff := cmp.Diff(want, issueStrings(got)); diff != "" {
+		t.Errorf("migrate mismatch (-want +got):\n%v", diff)
+	}
+
+	// The inferred renames were not applied.
+	g := design.Graph
+	if diff := cmp.Diff([]string{"x"}, g.Nodes[0].GetInputs()); diff != "" {
+		t.Errorf("MakeScalar inputs mismatch (-want +got):\n%v", diff)
+	}
+	if diff := cmp.Diff([]string{"value"}, g.Nodes[0].GetOutputs()); diff != "" {
+		t.Errorf("MakeScalar outputs mismatch (-want +got):\n%v", diff)
+	}
+	if got := g.Nodes[1].Inputs[0].Kind.Connection.ParamName; got != "value" {
+		t.Errorf("Helix.turns connection = %q, want %q", got, "value")
+	}
+	if pv := g.ExternalParameters.ParamValues[0]; pv.NodeIdx != 0 || pv.ParamName != "x" {
+		t.Errorf("MakeScalar param = %v,%v, want 0,x", pv.NodeIdx, pv.ParamName)
+	}
+}
+
+func TestMigrate_Migrations(t *testing.T) {
+	migrations := []*Migration{
+		{Before: ast.Version{Minor: 2}, OpName: "Helix", From: "size", To: "height"},
+	}
+
+	tests := []struct {
+		name        string
+		version     ast.Version
+		want        []string
+		wantVersion ast.Version
+	}{
+		{
+			name:    "older designs are migrated",
+			version: ast.Version{Minor: 1},
+			want: []string{
+				`node 0 (MakeScalar): renamed input "x" -> "value" (inferred)`,
+				`node 0 (MakeScalar): renamed output "value" -> "x" (inferred)`,
+				`node 1 (Helix): renamed input "size" -> "height" (fixed)`,
+				`node 1 (Helix): retyped input "height": BJK_VECTOR -> BJK_SCALAR (fixed)`,
+			},
+			wantVersion: ast.Version{Minor: 2},
+		},
+		{
+			name:    "newer designs are not",
+			version: ast.Version{Minor: 2},
+			want: []string{
+				`node 0 (MakeScalar): renamed input "x" -> "value" (inferred)`,
+				`node 0 (MakeScalar): renamed output "value" -> "x" (inferred)`,
+				`node 1 (Helix): removed input "size" (fixed)`,
+				`node 1 (Helix): missing input "height" (fixed)`,
+			},
+			wantVersion: ast.Version{Minor: 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			design := migrateDesign()
+			design.Version = tt.version
+			got := migrate(design, migrateLibrary(), migrations, true)
+			if diff := cmp.Diff(tt.want, issueStrings(got)); diff != "" {
+				t.Errorf("migrate mismatch (-want +got):\n%v", diff)
+			}
+			if design.Version != tt.wantVersion {
+				t.Errorf("Version = %v, want %v", design.Version, tt.wantVersion)
+			}
+		})
+	}
+}
+
+func TestMigrate_BrokenConnection(t *testing.T) {
+	library := migrateLibrary()
+	library["MakeScalar"].Outputs = []*ast.Output{{Name: "x", DataType: "vec3"}}
+	newDesign := func() *ast.BJK {
+		return &ast.BJK{
+			Graph: &ast.Graph{
+				Nodes: []*ast.Node{
+					{
+						OpName:  "MakeScalar",
+						Inputs:  []*ast.Input{{Name: "value", DataType: "BJK_SCALAR", Kind: ast.DependencyKind{External: &ast.External{}}}},
+						Outputs: []*ast.Output{{Name: "x", DataType: "BJK_SCALAR"}},
+					},
+					{
+						OpName: "Helix",
+						Inputs: []*ast.Input{
+							{Name: "turns", DataType: "BJK_SCALAR", Kind: ast.DependencyKind{Connection: &ast.Connection{NodeIdx: 0, ParamName: "x"}}},
+							{Name: "segments", DataType: "BJK_SCALAR", Kind: ast.DependencyKind{External: &ast.External{}}},
+							{Name: "height", DataType: "BJK_SCALAR", Kind: ast.DependencyKind{External: &ast.External{}}},
+						},
+						Outputs: []*ast.Output{{Name: "out_mesh", DataType: "BJK_MESH"}},
+					},
+				},
+			},
+		}
+	}
+
+	got := migrate(newDesign(), library, nil, false)
+	want := []string{
+		`node 0 (MakeScalar): retyped output "x": BJK_SCALAR -> BJK_VECTOR`,
+		`node 1 (Helix): broken connection "turns": BJK_VECTOR -> BJK_SCALAR`,
+	}
+	if diff := cmp.Diff(want, issueStrings(got)); diff != "" {
+		t.Errorf("check mismatch (-want +got):\n%v", diff)
+	}
+
+	design := newDesign()
+	got = migrate(design, library, nil, true)
+	want = []string{
+		`node 0 (MakeScalar): retyped output "x": BJK_SCALAR -> BJK_VECTOR (fixed)`,
+		`node 1 (Helix): broken connection "turns": BJK_VECTOR -> BJK_SCALAR (fixed)`,
+	}
+	if diff := cmp.Diff(want, issueStrings(got)); diff != "" {
+		t.Errorf("fix mismatch (-want +got):\n%v", diff)
+	}
+	turns := design.Graph.Nodes[1].Inputs[0]
+	if turns.Kind.Connection != nil || turns.Kind.External == nil {
+		t.Errorf("turns is still connected: %+v", turns.Kind)
+	}
+	if err := design.Validate(); err != nil {
+		t.Errorf("migrated design is invalid: %v", err)
+	}
+}
+
+func issueStrings(issues []*MigrationIssue) []string {
+	var result []string
+	for _, issue := range issues {
+		result = append(result, issue.String())
+	}
+	return result
+}
@@ -10,6 +10,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	"github.com/gmlewis/go-bjk/ast"
 	"github.com/mitchellh/go-homedir"
@@ -20,6 +21,14 @@ import (
 type Client struct {
 	Nodes map[string]*ast.Node
 
+	// Constraints selects how Eval (and new Builders) handle values that
+	// violate the constraints declared by the inputs of the Lua nodes.
+	Constraints ConstraintPolicy
+
+	// Migrations are the port renames that CheckDesign and Migrate apply
+	// to designs older than their versions (see Migration).
+	Migrations []*Migration
+
 	debug bool
 	ls    *lua.LState
 
@@ -29,6 +38,10 @@ type Client struct {
 
 	// used during Eval:
 	extParamsLookup map[string]*ast.ValueEnum
+
+	// warned records the soft-limit violations that were already logged.
+	warnedMu sync.Mutex
+	warned   map[string]bool
 }
 
 // New creates a new instance of nodes.Client.
@@ -0,0 +1,93 @@
+package nodes
+
+import (
+	"fmt"
+	"strings"
+)
+
+// NodeRef is a handle to a node (or an instance of a group) in a Builder.
+// Its ports can be passed to Connect (and to Input or Output within a group)
+// without building up dotted strings by hand.
+type NodeRef struct {
+	// Name is the full name of the node (e.g. "Helix.wire-1").
+	Name string
+}
+
+// Out returns a handle to the node's named output port.
+func (n NodeRef) Out(name string) PortRef {
+	return PortRef{Node: n.Name, Port: name}
+}
+
+// In returns a handle to the node's named input port.
+func (n NodeRef) In(name string) PortRef {
+	return PortRef{Node: n.Name, Port: name}
+}
+
+func (n NodeRef) String() string { return n.Name }
+
+// PortRef is a handle to an input or output port of a node.
+// Unlike a dotted string, the node name is never split, so
+// labels may themselves contain dots.
+type PortRef struct {
+	Node string
+	Port string
+}
+
+func (p PortRef) String() string {
+	if p.Port == "" {
+		return p.Node
+	}
+	return p.Node + "." + p.Port
+}
+
+// AddNodeRef is like AddNode but returns a handle to the new node.
+// If the name has no label, the handle refers to the node's auto-generated name.
+func (b *Builder) AddNodeRef(name string, args ...any) NodeRef {
+	if b.isGroup {
+		before := len(b.groupRecorder)
+		if b.AddNode(name, args...); len(b.groupRecorder) == before+1 {
+			name = b.groupRecorder[before].node
+		}
+		return NodeRef{Name: name}
+	}
+
+	before := len(b.NodeOrder)
+	b.AddNode(name, args...)
+	if _, ok := b.c.Nodes[name]; ok && len(b.NodeOrder) == before+1 {
+		name = b.NodeOrder[before]
+	}
+	return NodeRef{Name: name}
+}
+
+// Ref returns a handle to a node that was previously added by name.
+func (b *Builder) Ref(name string) NodeRef {
+	return NodeRef{Name: name}
+}
+
+// toPortRef converts a port given as a string (split at its last dot)
+// or as a PortRef to a PortRef.
+func toPortRef(port any) (PortRef, error) {
+	switch p := port.(type) {
+	case PortRef:
+		return p, nil
+	case *PortRef:
+		if p == nil {
+			return PortRef{}, fmt.Errorf("nil PortRef")
+		}
+		return *p, nil
+	case string:
+		i := strings.LastIndex(p, ".")
+		if i < 0 {
+			return PortRef{Node: p}, nil
+		}
+		return PortRef{Node: p[:i], Port: p[i+1:]}, nil
+	default:
+		return PortRef{}, fmt.Errorf("port %v has type %T, want string or PortRef", port, port)
+	}
+}
+
+// nodeType returns the type of a node from its full name (e.g. "Helix" from "Helix.wire-1").
+func nodeType(name string) string {
+	t, _, _ := strings.Cut(name, ".")
+	return t
+}
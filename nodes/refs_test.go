@@ -0,0 +1,85 @@
+package nodes
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestToPortRef(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      any
+		want    PortRef
+		wantErr bool
+	}{
+		{name: "dotted string", in: "Helix.wire-1.out_mesh", want: PortRef{Node: "Helix.wire-1", Port: "out_mesh"}},
+		{name: "node only", in: "Helix", want: PortRef{Node: "Helix"}},
+		{name: "PortRef", in: PortRef{Node: "Helix.a.b", Port: "out_mesh"}, want: PortRef{Node: "Helix.a.b", Port: "out_mesh"}},
+		{name: "*PortRef", in: &PortRef{Node: "Helix", Port: "size"}, want: PortRef{Node: "Helix", Port: "size"}},
+		{name: "nil *PortRef", in: (*PortRef)(nil), wantErr: true},
+		{name: "bad type", in: 42, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := toPortRef(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("toPortRef err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("toPortRef mismatch (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestNodeRef(t *testing.T) {
+	b := fakeBuilder()
+	h1 := b.AddNodeRef("Helix.h1")
+	h2 := b.AddNodeRef("Helix")
+	merge := b.AddNodeRef("MergeMeshes.m")
+	b.Connect(h1.Out("out_mesh"), merge.In("mesh_a")).
+		Connect(h2.Out("out_mesh"), "MergeMeshes.m.mesh_b")
+
+	if got, want := h2.String(), "Helix.node-2"; got != want {
+		t.Errorf("unlabeled NodeRef = %q, want %q", got, want)
+	}
+	want := map[string]string{
+		"MergeMeshes.m.mesh_a": "Helix.h1.out_mesh",
+		"MergeMeshes.m.mesh_b": "Helix.node-2.out_mesh",
+	}
+	if diff := cmp.Diff(want, b.InputsAlreadyConnected); diff != "" {
+		t.Errorf("InputsAlreadyConnected mismatch (-want +got):\n%v", diff)
+	}
+	if len(b.errs) > 0 {
+		t.Errorf("unexpected errors: %v", b.errs)
+	}
+}
+
+func TestNodeRef_Group(t *testing.T) {
+	b := fakeBuilder()
+	var h NodeRef
+	b.NewGroup("Coil.one", func(b *Builder) *Builder {
+		h = b.AddNodeRef("Helix")
+		m := b.AddNodeRef("MergeMeshes")
+		return b.Connect(h.Out("out_mesh"), m.In("mesh_a")).
+			Input("angle", h.In("start_angle")).
+			Output(m.Out("out_mesh"), "out_mesh")
+	}, "angle=45")
+	if len(b.errs) > 0 {
+		t.Fatalf("unexpected errors: %v", b.errs)
+	}
+
+	if got, want := h.String(), "Helix.node-1"; got != want {
+		t.Errorf("unlabeled group NodeRef = %q, want %q", got, want)
+	}
+	want := map[string]string{
+		"MergeMeshes.Coil.one.node-2.mesh_a": "Helix.Coil.one.node-1.out_mesh",
+		"Helix.Coil.one.node-1.start_angle":  "45",
+		"Coil.one.angle":                     "45",
+	}
+	if diff := cmp.Diff(want, b.InputsAlreadyConnected); diff != "" {
+		t.Errorf("InputsAlreadyConnected mismatch (-want +got):\n%v", diff)
+	}
+}
@@ -0,0 +1,84 @@
+package nodes
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/gmlewis/go-bjk/ast"
+)
+
+// IsDefaultValue reports whether ve is the default value of the named input
+// of the named node type as declared by the Blackjack Lua node library.
+func (c *Client) IsDefaultValue(opName, inputName string, ve *ast.ValueEnum) bool {
+	n, ok := c.Nodes[opName]
+	if !ok {
+		return false
+	}
+	input, ok := n.GetInput(inputName)
+	if !ok || input.DataType == "mesh" || input.DataType == "file" {
+		return false
+	}
+	def, err := getValueEnum(input)
+	if err != nil {
+		return false
+	}
+	return reflect.DeepEqual(def, ve)
+}
+
+// RenderOptions returns options for rendering a design with ast.Graph's
+// ToDOT or ToMermaid that hide all parameters that have default values.
+func (c *Client) RenderOptions(g *ast.Graph) *ast.RenderOptions {
+	return &ast.RenderOptions{
+		IsDefault: func(nodeIdx uint64, paramName string, ve *ast.ValueEnum) bool {
+			if nodeIdx >= uint64(len(g.Nodes)) {
+				return false
+			}
+			return c.IsDefaultValue(g.Nodes[nodeIdx].OpName, paramName, ve)
+		},
+	}
+}
+
+// ToDOT renders the nodes and connections added to the builder so far in
+// Graphviz DOT format, labeling each node with its full name.
+func (b *Builder) ToDOT() (string, error) {
+	g, opts, err := b.renderGraph()
+	if err != nil {
+		return "", err
+	}
+	return g.ToDOT(opts), nil
+}
+
+// ToMermaid renders the nodes and connections added to the builder so far
+// as a Mermaid flowchart, labeling each node with its full name.
+func (b *Builder) ToMermaid() (string, error) {
+	g, opts, err := b.renderGraph()
+	if err != nil {
+		return "", err
+	}
+	return g.ToMermaid(opts), nil
+}
+
+// renderGraph creates a graph of the builder's current state without
+// the side effects of calling Build.
+func (b *Builder) renderGraph() (*ast.Graph, *ast.RenderOptions, error) {
+	g := &ast.Graph{ExternalParameters: &ast.ExternalParameters{}}
+	for _, k := range b.NodeOrder {
+		node, ok := b.Nodes[k]
+		if !ok {
+			return nil, nil, fmt.Errorf("programming error: missing node '%v'", k)
+		}
+		g.Nodes = append(g.Nodes, node)
+
+		pvs, err := externalParamValues(node)
+		if err != nil {
+			return nil, nil, fmt.Errorf("node '%v': %w", k, err)
+		}
+		g.ExternalParameters.ParamValues = append(g.ExternalParameters.ParamValues, pvs...)
+	}
+	if len(g.Nodes) > 0 {
+		dn := uint64(len(g.Nodes) - 1)
+		g.DefaultNode = &dn
+	}
+
+	return g, b.c.RenderOptions(g), nil
+}
@@ -0,0 +1,41 @@
+package nodes
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuilderRender(t *testing.T) {
+	t.Parallel()
+	b := c.NewBuilder().
+		AddNode("MakeScalar.turns", "x=2").
+		AddNode("Helix.wire-1").
+		Connect("MakeScalar.turns.x", "Helix.wire-1.turns")
+
+	dot, err := b.ToDOT()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`n0 [label="MakeScalar\nturns\nx = Scalar(2.0)"];`,
+		`n1 [label="Helix\nwire-1"`,
+		`n0 -> n1 [label="x → turns"];`,
+	} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("ToDOT missing %q:\n%v", want, dot)
+		}
+	}
+
+	mermaid, err := b.ToMermaid()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`n0["MakeScalar<br/>turns<br/>x = Scalar(2.0)"]`,
+		`n0 -->|"x → turns"| n1`,
+	} {
+		if !strings.Contains(mermaid, want) {
+			t.Errorf("ToMermaid missing %q:\n%v", want, mermaid)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package nodes
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RepeatOption configures a call to Repeat.
+type RepeatOption func(*repeatOptions)
+
+type repeatOptions struct {
+	label    string
+	start    int
+	connects []repeatConnect
+	before   []func(b *Builder, i int, instance NodeRef) *Builder
+	each     []func(b *Builder, i int, instance NodeRef) *Builder
+	merge    string
+}
+
+type repeatConnect struct {
+	from  any
+	input string
+}
+
+// RepeatLabel sets the label of the instances created by Repeat, which are
+// named "<type>.<label>-<n>" (e.g. "CoilPair.pair-3"). The default label is "repeat".
+func RepeatLabel(label string) RepeatOption {
+	return func(o *repeatOptions) { o.label = label }
+}
+
+// RepeatStart sets the number of the first instance created by Repeat
+// (e.g. RepeatStart(2) names the instances "<type>.<label>-2", "<type>.<label>-3", ...).
+// The default is 1. Names that are already in use are still skipped.
+func RepeatStart(n int) RepeatOption {
+	return func(o *repeatOptions) { o.start = n }
+}
+
+// RepeatConnect connects the `from` node.output_port (a string or a PortRef)
+// to the named input of every instance created by Repeat.
+func RepeatConnect(from any, input string) RepeatOption {
+	return func(o *repeatOptions) { o.connects = append(o.connects, repeatConnect{from: from, input: input}) }
+}
+
+// RepeatBefore calls fn before each instance is created by Repeat so that it
+// can add index-dependent nodes that precede the instance in the design.
+func RepeatBefore(fn func(b *Builder, i int, instance NodeRef) *Builder) RepeatOption {
+	return func(o *repeatOptions) { o.before = append(o.before, fn) }
+}
+
+// RepeatEach calls fn after each instance is created by Repeat (and connected
+// by RepeatConnect) so that it can add index-dependent nodes and connections.
+func RepeatEach(fn func(b *Builder, i int, instance NodeRef) *Builder) RepeatOption {
+	return func(o *repeatOptions) { o.each = append(o.each, fn) }
+}
+
+// RepeatMerge merges the named output of every instance created by Repeat
+// into the design with MergeMesh.
+func RepeatMerge(output string) RepeatOption {
+	return func(o *repeatOptions) { o.merge = output }
+}
+
+// Repeat instantiates the named group (or node type) count times.
+// args(i) returns the args (like those of AddNode) of the i'th instance,
+// starting at 0, and may be nil if the instances need no args.
+// Each instance gets a unique name (see RepeatLabel) and the opts can
+// connect, extend, and merge the instances.
+func (b *Builder) Repeat(count int, groupName string, args func(i int) []any, opts ...RepeatOption) *Builder {
+	o := &repeatOptions{label: "repeat", start: 1}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if count < 0 {
+		b.errs = append(b.errs, fmt.Errorf("Repeat(%v, %q): count must not be negative", count, groupName))
+		return b
+	}
+	if groupName == "" || strings.Contains(groupName, ".") {
+		b.errs = append(b.errs, fmt.Errorf("Repeat(%v, %q): want the name of a group or node type without a label", count, groupName))
+		return b
+	}
+	if o.label == "" || strings.Contains(o.label, ".") {
+		b.errs = append(b.errs, fmt.Errorf("Repeat(%v, %q): bad label %q", count, groupName, o.label))
+		return b
+	}
+	if o.merge != "" && b.isGroup {
+		b.errs = append(b.errs, fmt.Errorf("Repeat(%v, %q): RepeatMerge cannot be used within a group", count, groupName))
+		return b
+	}
+
+	n := o.start - 1
+	for i := 0; i < count; i++ {
+		var name string
+		for name == "" || b.nameInUse(name) {
+			n++
+			name = fmt.Sprintf("%v.%v-%v", groupName, o.label, n)
+		}
+
+		instance := NodeRef{Name: name}
+		for _, fn := range o.before {
+			b = fn(b, i, instance)
+		}
+
+		var instanceArgs []any
+		if args != nil {
+			instanceArgs = args(i)
+		}
+		b = b.AddNode(name, instanceArgs...)
+
+		for _, c := range o.connects {
+			b = b.Connect(c.from, instance.In(c.input))
+		}
+		for _, fn := range o.each {
+			b = fn(b, i, instance)
+		}
+		if o.merge != "" {
+			b = b.MergeMesh(instance.Out(o.merge).String())
+		}
+	}
+
+	return b
+}
+
+// nameInUse reports whether a node or group instance of the given full name
+// was already added to the builder.
+func (b *Builder) nameInUse(name string) bool {
+	if _, ok := b.Nodes[name]; ok {
+		return true
+	}
+	if _, ok := b.groupInstances[name]; ok {
+		return true
+	}
+	for _, step := range b.groupRecorder {
+		if step.action == "AddNode" && step.node == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,151 @@
+package nodes
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	lua "github.com/yuin/gopher-lua"
+)
+
+func TestRepeat(t *testing.T) {
+	var each []string
+	b := fakeWireBuilder(t).
+		AddNode("MakeScalar.angle").
+		Repeat(3, "Wire", func(i int) []any {
+			return []any{Scalar("segments", float64(10+i)), Vector("size", Vec3{1, 1, 1})}
+		},
+			RepeatLabel("w"),
+			RepeatConnect("MakeScalar.angle.x", "angle"),
+			RepeatEach(func(b *Builder, i int, instance NodeRef) *Builder {
+				each = append(each, fmt.Sprintf("%v:%v", i, instance))
+				return b
+			}),
+			RepeatMerge("out_mesh"))
+	design, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff([]string{"0:Wire.w-1", "1:Wire.w-2", "2:Wire.w-3"}, each); diff != "" {
+		t.Errorf("RepeatEach mismatch (-want +got):\n%v", diff)
+	}
+	angleIdx := b.Nodes["MakeScalar.angle"].Index
+	for i := 0; i < 3; i++ {
+		name := fmt.Sprintf("Helix.Wire.w-%v.h", i+1)
+		helix, ok := b.Nodes[name]
+		if !ok {
+			t.Fatalf("missing node %v", name)
+		}
+		if got, want := helix.Inputs[1].Props["default"], lua.LNumber(10+i); got != want {
+			t.Errorf("%v segments = %v, want %v", name, got, want)
+		}
+		if conn := helix.Inputs[0].Kind.Connection; conn == nil || conn.NodeIdx != angleIdx {
+			t.Errorf("%v start_angle connection = %+v, want from node %v", name, conn, angleIdx)
+		}
+	}
+
+	// 1 MakeScalar + 3 Helix + 2 MergeMeshes
+	if got, want := len(design.Graph.Nodes), 6; got != want {
+		t.Errorf("got %v nodes, want %v", got, want)
+	}
+	if got, want := b.lastMergeMesh, "MergeMeshes.5.out_mesh"; got != want {
+		t.Errorf("lastMergeMesh = %q, want %q", got, want)
+	}
+
+	// Repeating again continues the numbering.
+	b = b.Repeat(2, "Wire", func(int) []any { return []any{Vector("size", Vec3{1, 1, 1})} }, RepeatLabel("w"))
+	for _, name := range []string{"Helix.Wire.w-4.h", "Helix.Wire.w-5.h"} {
+		if _, ok := b.Nodes[name]; !ok {
+			t.Errorf("missing node %v", name)
+		}
+	}
+}
+
+func TestRepeat_Chained(t *testing.T) {
+	last := "Helix.first.out_mesh"
+	b := fakeBuilder().
+		AddNode("Helix.first").
+		Repeat(2, "MergeMeshes", nil, RepeatEach(func(b *Builder, i int, instance NodeRef) *Builder {
+			b = b.Connect(last, instance.In("mesh_a"))
+			last = instance.Out("out_mesh").String()
+			return b
+		}))
+	if len(b.errs) > 0 {
+		t.Fatal(b.Errors())
+	}
+	if diff := cmp.Diff([]string{"Helix.first", "MergeMeshes.repeat-1", "MergeMeshes.repeat-2"}, b.NodeOrder); diff != "" {
+		t.Errorf("NodeOrder mismatch (-want +got):\n%v", diff)
+	}
+	if got := b.InputsAlreadyConnected["MergeMeshes.repeat-2.mesh_a"]; got != "MergeMeshes.repeat-1.out_mesh" {
+		t.Errorf("mesh_a connected to %q, want MergeMeshes.repeat-1.out_mesh", got)
+	}
+}
+
+func TestRepeat_StartAndBefore(t *testing.T) {
+	b := fakeBuilder().
+		Repeat(2, "MergeMeshes", nil,
+			RepeatLabel("m"),
+			RepeatStart(2),
+			RepeatBefore(func(b *Builder, i int, instance NodeRef) *Builder {
+				return b.AddNode("Helix." + strings.TrimPrefix(instance.Name, "MergeMeshes."))
+			}),
+			RepeatEach(func(b *Builder, i int, instance NodeRef) *Builder {
+				return b.Connect("Helix."+strings.TrimPrefix(instance.Name, "MergeMeshes.")+".out_mesh", instance.In("mesh_a"))
+			}))
+	if len(b.errs) > 0 {
+		t.Fatal(b.Errors())
+	}
+	want := []string{"Helix.m-2", "MergeMeshes.m-2", "Helix.m-3", "MergeMeshes.m-3"}
+	if diff := cmp.Diff(want, b.NodeOrder); diff != "" {
+		t.Errorf("NodeOrder mismatch (-want +got):\n%v", diff)
+	}
+}
+
+func TestRepeat_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		fn      func(b *Builder) *Builder
+		wantErr string
+	}{
+		{
+			name:    "negative count",
+			fn:      func(b *Builder) *Builder { return b.Repeat(-1, "Wire", nil) },
+			wantErr: "count must not be negative",
+		},
+		{
+			name:    "instance name",
+			fn:      func(b *Builder) *Builder { return b.Repeat(2, "Wire.w", nil) },
+			wantErr: "without a label",
+		},
+		{
+			name:    "bad label",
+			fn:      func(b *Builder) *Builder { return b.Repeat(2, "Wire", nil, RepeatLabel("a.b")) },
+			wantErr: `bad label "a.b"`,
+		},
+		{
+			name:    "unknown type",
+			fn:      func(b *Builder) *Builder { return b.Repeat(1, "Wier", nil) },
+			wantErr: `unknown node type 'Wier'; did you mean "Wire"?`,
+		},
+		{
+			name: "merge within group",
+			fn: func(b *Builder) *Builder {
+				return b.NewGroup("Wires", func(b *Builder) *Builder {
+					return b.Repeat(2, "Wire", nil, RepeatMerge("out_mesh"))
+				}).Groups["Wires"]
+			},
+			wantErr: "RepeatMerge cannot be used within a group",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := tt.fn(fakeWireBuilder(t))
+			if errs := b.Errors(); len(errs) != 1 || !strings.Contains(errs[0].Error(), tt.wantErr) {
+				t.Errorf("errs = %v, want %v", errs, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package nodes
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gmlewis/go-bjk/ast"
+	lua "github.com/yuin/gopher-lua"
+)
+
+type argKind int
+
+const (
+	argString argKind = iota // legacy "name=value" string
+	argScalar
+	argVector
+	argEnum
+	argStr
+)
+
+// Arg is a typed value for one of a node's inputs. It is created by
+// Scalar, Vector, Enum, or Str and can be passed to AddNode (and to
+// AddNodeRef or NewGroup) in place of a "name=value" string.
+type Arg struct {
+	// Name is the name of the input.
+	Name string
+
+	kind argKind
+	x    float64
+	v    Vec3
+	s    string
+}
+
+// Scalar returns an Arg that sets the named scalar input to x.
+func Scalar(name string, x float64) Arg { return Arg{Name: name, kind: argScalar, x: x} }
+
+// Vector returns an Arg that sets the named vector input to v.
+func Vector(name string, v Vec3) Arg { return Arg{Name: name, kind: argVector, v: v} }
+
+// Enum returns an Arg that selects the named value of the named enum input.
+func Enum(name, value string) Arg { return Arg{Name: name, kind: argEnum, s: value} }
+
+// Str returns an Arg that sets the named string input to s.
+func Str(name, s string) Arg { return Arg{Name: name, kind: argStr, s: s} }
+
+// String returns the Arg in the legacy "name=value" form.
+func (a Arg) String() string {
+	return a.Name + "=" + a.value()
+}
+
+func (a Arg) value() string {
+	switch a.kind {
+	case argScalar:
+		return formatFloat(a.x)
+	case argVector:
+		return fmt.Sprintf("vector(%v,%v,%v)", formatFloat(a.v.X), formatFloat(a.v.Y), formatFloat(a.v.Z))
+	default:
+		return a.s
+	}
+}
+
+// toArgs converts the args passed to AddNode to Args.
+// Each arg is either a "name=value" string or an Arg.
+func toArgs(args []any) ([]Arg, error) {
+	result := make([]Arg, 0, len(args))
+	for _, arg := range args {
+		switch v := arg.(type) {
+		case Arg:
+			if v.Name == "" {
+				return nil, fmt.Errorf("bad arg %v, name cannot be empty", v)
+			}
+			result = append(result, v)
+		case string:
+			lhs, rhs, err := splitArg(v)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, Arg{Name: lhs, kind: argString, s: rhs})
+		default:
+			return nil, fmt.Errorf("bad arg %v of type %T, want string or nodes.Arg", arg, arg)
+		}
+	}
+	return result, nil
+}
+
+// set sets the value of the input from the Arg.
+func (a Arg) set(input *ast.Input) error {
+	if a.kind == argString {
+		return setInputProp(input, strings.TrimSpace(a.s))
+	}
+
+	t, _ := input.Props["type"].(lua.LString)
+	switch {
+	case a.kind == argScalar && t == "scalar":
+		return setInputScalar(t, input, a.x)
+	case a.kind == argVector && t == "vec3":
+		return setInputVector(t, input, a.v)
+	case a.kind == argEnum && t == "enum":
+		return setInputEnumValue(t, input, a.s)
+	case a.kind == argStr && t == "string":
+		return setInputStringValue(t, input, a.s)
+	case a.kind == argStr && (t == "selection" || t == "lua_string"):
+		return setInputSelectionValue(t, input, a.s)
+	}
+
+	kinds := map[argKind]string{argScalar: "Scalar", argVector: "Vector", argEnum: "Enum", argStr: "Str"}
+	return fmt.Errorf("input %q has type %q, cannot set it to %v with %v", input.Name, t, a.value(), kinds[a.kind])
+}
@@ -0,0 +1,135 @@
+package nodes
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	lua "github.com/yuin/gopher-lua"
+)
+
+func TestAddNode_TypedArgs(t *testing.T) {
+	b := fakeBuilder()
+	b.AddNode("Helix.typed", Scalar("start_angle", 0.1+0.2), Vector("size", Vec3{X: 1.5, Y: 2, Z: 1.0 / 3}), Enum("direction", "Clockwise")).
+		AddNode("Helix.legacy", "start_angle=0.3", "size=vector(1.5,2,3)", "direction=Clockwise")
+	if len(b.errs) > 0 {
+		t.Fatalf("unexpected errors: %v", b.errs)
+	}
+
+	props := func(name string) map[string]lua.LValue {
+		result := map[string]lua.LValue{}
+		for _, input := range b.Nodes[name].Inputs {
+			switch input.Name {
+			case "start_angle", "size":
+				result[input.Name] = input.Props["default"]
+			case "direction":
+				result[input.Name] = input.Props["selected"]
+			}
+		}
+		return result
+	}
+
+	typed := props("Helix.typed")
+	if got, want := typed["start_angle"], lua.LNumber(0.1+0.2); got != want {
+		t.Errorf("start_angle = %v, want %v", got, want)
+	}
+	if diff := cmp.Diff(&Vec3{X: 1.5, Y: 2, Z: 1.0 / 3}, typed["size"].(*lua.LUserData).Value); diff != "" {
+		t.Errorf("size mismatch (-want +got):\n%v", diff)
+	}
+	if got, want := typed["direction"], lua.LNumber(0); got != want {
+		t.Errorf("direction = %v, want %v", got, want)
+	}
+	if got, want := props("Helix.legacy")["direction"], typed["direction"]; got != want {
+		t.Errorf("legacy direction = %v, want %v", got, want)
+	}
+
+	if got, want := b.InputsAlreadyConnected["Helix.typed.size"], "vector(1.5,2,0.3333333333333333)"; got != want {
+		t.Errorf("InputsAlreadyConnected = %q, want %q", got, want)
+	}
+}
+
+func TestAddNode_TypedGroupArgs(t *testing.T) {
+	b := fakeBuilder()
+	b.NewGroup("Coil.one", func(b *Builder) *Builder {
+		return b.AddNode("Helix.coil").
+			Input("angle", "Helix.coil.start_angle").
+			Output("Helix.coil.out_mesh", "out_mesh")
+	}, Scalar("angle", 1.0/3))
+	if len(b.errs) > 0 {
+		t.Fatalf("unexpected errors: %v", b.errs)
+	}
+
+	n := b.Nodes["Helix.Coil.one.coil"]
+	if n == nil {
+		t.Fatalf("group node not found, got %v", b.NodeOrder)
+	}
+	if got, want := n.Inputs[0].Props["default"], lua.LNumber(1.0/3); got != want {
+		t.Errorf("start_angle = %v, want %v", got, want)
+	}
+}
+
+func TestAddNode_TypedArgErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		arg  any
+		want string
+	}{
+		{name: "scalar to enum", arg: Scalar("direction", 1), want: `input "direction" has type "enum", cannot set it to 1 with Scalar`},
+		{name: "str to vector", arg: Str("size", "big"), want: `input "size" has type "vec3", cannot set it to big with Str`},
+		{name: "unknown enum value", arg: Enum("direction", "Up"), want: `value "Up" is not one of: Clockwise, Counter-Clockwise`},
+		{name: "empty name", arg: Scalar("", 1), want: "name cannot be empty"},
+		{name: "bad type", arg: 3.0, want: "want string or nodes.Arg"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := fakeBuilder()
+			b.AddNode("Helix", tt.arg)
+			if len(b.errs) != 1 || !strings.Contains(b.errs[0].Error(), tt.want) {
+				t.Errorf("errs = %v, want error containing %q", b.errs, tt.want)
+			}
+		})
+	}
+}
+
+func TestArg_String(t *testing.T) {
+	tests := []struct {
+		arg  Arg
+		want string
+	}{
+		{arg: Scalar("turns", 2.5), want: "turns=2.5"},
+		{arg: Vector("size", Vec3{X: 1, Y: 0.5, Z: -2}), want: "size=vector(1,0.5,-2)"},
+		{arg: Enum("direction", "Clockwise"), want: "direction=Clockwise"},
+		{arg: Str("d", "M 0 0 L 1 1"), want: "d=M 0 0 L 1 1"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.arg.String(); got != tt.want {
+			t.Errorf("String = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestAddNode_TypedArgsBlackjack(t *testing.T) {
+	t.Parallel()
+	if c == nil {
+		t.Fatalf("c is nil")
+	}
+	b := c.NewBuilder().
+		AddNode("Helix.typed", Scalar("start_angle", 180), Scalar("segments", 24), Enum("direction", "Counter-Clockwise"))
+	if len(b.errs) > 0 {
+		t.Fatalf("unexpected errors: %v", b.errs)
+	}
+	for _, input := range b.Nodes["Helix.typed"].Inputs {
+		if input.Name == "direction" && input.Props["selected"] != lua.LNumber(1) {
+			t.Errorf("direction selected = %v, want 1", input.Props["selected"])
+		}
+	}
+
+	b = c.NewBuilder().AddNode("Helix.typed", Enum("direction", "Sideways"))
+	var ce *ConstraintError
+	if len(b.errs) != 1 || !errors.As(b.errs[0], &ce) || ce.Constraint != "enum" {
+		t.Errorf("errs = %v, want an enum *ConstraintError", b.errs)
+	}
+}
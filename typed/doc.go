@@ -0,0 +1,11 @@
+// Package typed provides a typed API for adding Blackjack nodes to a
+// nodes.Builder. It is generated from the node library in the Blackjack
+// repo by cmd/bjk-gen-typed, so run "go generate" in this directory
+// to create (or, after updating the Blackjack repo, update) nodes_gen.go.
+//
+// The generated file is not checked in because it depends on the version
+// of the local Blackjack repo, so until it is generated this package
+// provides no API. See nodes.Client.GenerateGo for what is generated.
+package typed
+
+//go:generate go run ../cmd/bjk-gen-typed -o nodes_gen.go